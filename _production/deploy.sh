@@ -33,4 +33,7 @@ systemctl start drafty-backend
 echo "...status"
 systemctl status drafty-backend --no-pager
 
+echo "...health"
+/vol/drafty3/bin/drafty-backend healthcheck || true
+
 echo "::: Complete! :) :::"
\ No newline at end of file
@@ -0,0 +1,215 @@
+// Package columns keeps the presentation hints of each SuggestionType, which tell the grid how to show and
+// edit a column, and compares them with the YAML column config the frontend used to be driven by.
+package columns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+)
+
+// ValueTypes are the types a column's values can be parsed as
+var ValueTypes = []string{"string", "string[]"}
+
+// EditModes are the ways a column's cells can be edited
+var EditModes = []string{"free_text", "dropdown", "dropdown_free_text", "multi_select"}
+
+// widths are a percent of the grid's width
+var widthPattern = regexp.MustCompile(`^\d+%$`)
+
+// Hints are the presentation hints of one column, named like the keys of the YAML config
+type Hints struct {
+	Type  string `yaml:"type" json:"type,omitempty"`
+	Width string `yaml:"width" json:"width,omitempty"`
+	Edit  string `yaml:"edit" json:"edit,omitempty"`
+}
+
+// Validate checks every hint that's set holds a value the frontend understands
+func (h Hints) Validate() error {
+	var problems []string
+	if h.Type != "" && !slices.Contains(ValueTypes, h.Type) {
+		problems = append(problems, fmt.Sprintf("type %q isn't one of %s", h.Type, strings.Join(ValueTypes, ", ")))
+	}
+	if h.Width != "" && !widthPattern.MatchString(h.Width) {
+		problems = append(problems, fmt.Sprintf("width %q isn't a percent like 20%%", h.Width))
+	}
+	if h.Edit != "" && !slices.Contains(EditModes, h.Edit) {
+		problems = append(problems, fmt.Sprintf("edit %q isn't one of %s", h.Edit, strings.Join(EditModes, ", ")))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// FromSuggestionType returns the hints stored on a SuggestionType, leaving the ones that aren't set empty
+func FromSuggestionType(st data_model.SuggestionType) Hints {
+	return Hints{Type: deref(st.ValueType), Width: deref(st.Width), Edit: deref(st.EditMode)}
+}
+
+// LoadYAML reads a YAML column config, which maps column names to their hints, and validates every column
+func LoadYAML(path string) (map[string]Hints, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]Hints
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	// report every invalid column at once so the file can be fixed in one go
+	var problems []string
+	for _, name := range sortedNames(config) {
+		if err := config[name].Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid columns in %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+	return config, nil
+}
+
+// Mismatch is a way the YAML config and the db disagree about a column
+type Mismatch struct {
+	Column  string `json:"column"`
+	Problem string `json:"problem"`
+}
+
+// String returns the mismatch as it's reported
+func (m Mismatch) String() string {
+	return m.Column + ": " + m.Problem
+}
+
+// Compare finds where the config and the hints stored in db disagree: config columns with no SuggestionType
+// of their name, active public SuggestionTypes missing from the config, and hints that differ
+func Compare(db *gorm.DB, config map[string]Hints) ([]Mismatch, error) {
+	sts, err := loadSuggestionTypes(db)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]data_model.SuggestionType, len(sts))
+	for _, st := range sts {
+		byName[strings.ToLower(deref(st.Name))] = st
+	}
+
+	var mismatches []Mismatch
+	for _, name := range sortedNames(config) {
+		st, ok := byName[strings.ToLower(name)]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Column: name, Problem: "no SuggestionType has this name"})
+			continue
+		}
+		stored := FromSuggestionType(st)
+		want := config[name]
+		for _, field := range []struct{ name, yaml, db string }{
+			{"type", want.Type, stored.Type},
+			{"width", want.Width, stored.Width},
+			{"edit", want.Edit, stored.Edit},
+		} {
+			if field.yaml != field.db {
+				mismatches = append(mismatches, Mismatch{
+					Column:  name,
+					Problem: fmt.Sprintf("%s is %q in the yaml but %q in the db", field.name, field.yaml, field.db),
+				})
+			}
+		}
+	}
+
+	// columns the grid shows that the config says nothing about
+	for _, st := range sts {
+		if st.IsActive != 1 || st.IsPrivate != 0 {
+			continue
+		}
+		if _, ok := findName(config, deref(st.Name)); !ok {
+			mismatches = append(mismatches, Mismatch{Column: deref(st.Name), Problem: "active column missing from the yaml"})
+		}
+	}
+	return mismatches, nil
+}
+
+// Import stores the hints of every config column on the SuggestionType of its name in one transaction,
+// returning how many were updated. columns with no SuggestionType are skipped, which Compare reports.
+func Import(db *gorm.DB, config map[string]Hints) (int, error) {
+	updated := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		sts, err := loadSuggestionTypes(tx)
+		if err != nil {
+			return err
+		}
+		for _, st := range sts {
+			name, ok := findName(config, deref(st.Name))
+			if !ok {
+				continue
+			}
+			hints := config[name]
+			if err := tx.Model(&data_model.SuggestionType{}).
+				Where("idSuggestionType = ?", st.IDSuggestionType).
+				Updates(map[string]interface{}{
+					"valueType": nullable(hints.Type),
+					"width":     nullable(hints.Width),
+					"editMode":  nullable(hints.Edit),
+				}).Error; err != nil {
+				return fmt.Errorf("update %s: %w", name, err)
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// function to load every SuggestionType with a name, in id order
+func loadSuggestionTypes(db *gorm.DB) ([]data_model.SuggestionType, error) {
+	var sts []data_model.SuggestionType
+	if err := db.Where("name IS NOT NULL").Order("idSuggestionType").Find(&sts).Error; err != nil {
+		return nil, fmt.Errorf("load suggestion types: %w", err)
+	}
+	return sts, nil
+}
+
+// function to find the config key of a column name, which matches regardless of case like GET /suggestiontypes/:name
+func findName(config map[string]Hints, name string) (string, bool) {
+	for key := range config {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// function to list the config's column names in order so reports are stable
+func sortedNames(config map[string]Hints) []string {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// function to read a nullable column, where null is empty
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// function to store an empty hint as null
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package columns
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// NormalizeValue normalizes a raw suggestion the way the grid and the CSV show it across string and string[]:
+// trimmed, a JSON array kept as a compact array of trimmed items, and a quoted JSON string unwrapped
+func NormalizeValue(raw string) string {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return ""
+	}
+
+	// if it's a JSON array string keep it that way but trim whitespace from each element in the array
+	if items, ok := arrayItems(s); ok {
+		normalized, err := json.Marshal(items)
+		if err == nil {
+			return string(normalized)
+		}
+	}
+
+	// if it's a quoted JSON string then unwrap it
+	var single string
+	if err := json.Unmarshal([]byte(s), &single); err == nil {
+		return strings.TrimSpace(single)
+	}
+
+	// otherwise just return the trimmed raw string
+	return s
+}
+
+// DisplayValue normalizes a raw suggestion like NormalizeValue and shows a multi select value as a comma
+// separated list, the way the edit history shows it
+func DisplayValue(raw string) string {
+	s := NormalizeValue(raw)
+	if items, ok := arrayItems(s); ok {
+		return strings.Join(items, ", ")
+	}
+	return s
+}
+
+// function to read a JSON array of strings with every item trimmed
+func arrayItems(s string) ([]string, bool) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, false
+	}
+	var items []string
+	if err := json.Unmarshal([]byte(s), &items); err != nil {
+		return nil, false
+	}
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items, true
+}
@@ -0,0 +1,23 @@
+package columns
+
+import "testing"
+
+func TestNormalizeAndDisplayValue(t *testing.T) {
+	tests := []struct {
+		raw, normalized, display string
+	}{
+		{"", "", ""},
+		{"  Brown ", "Brown", "Brown"},
+		{`"quoted "`, "quoted", "quoted"},
+		{`[" HCI", "PL "]`, `["HCI","PL"]`, "HCI, PL"},
+		{"[not json]", "[not json]", "[not json]"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeValue(tt.raw); got != tt.normalized {
+			t.Errorf("NormalizeValue(%q) = %q, want %q", tt.raw, got, tt.normalized)
+		}
+		if got := DisplayValue(tt.raw); got != tt.display {
+			t.Errorf("DisplayValue(%q) = %q, want %q", tt.raw, got, tt.display)
+		}
+	}
+}
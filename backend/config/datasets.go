@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// name of the registry file looked up in the db root when DATASETS_CONFIG isn't set
+const DatasetsFileName = "datasets.yaml"
+
+// name of the users db that lives next to the dataset dbs but isn't a dataset
+const UsersDBFileName = "users_gorm.db"
+
+// dataset names become route segments so keep them simple
+var datasetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// route segments under /api that a dataset can't take over
+var reservedDatasetNames = map[string]bool{
+	"admin":    true,
+	"datasets": true,
+	"health":   true,
+	"users":    true,
+}
+
+// Dataset is one dataset db mounted at /api/<Name>
+type Dataset struct {
+	Name string `yaml:"name"`
+	File string `yaml:"file"`
+	Path string `yaml:"-"`
+}
+
+// model of the datasets registry file
+type datasetsFile struct {
+	Datasets []Dataset `yaml:"datasets"`
+}
+
+// LoadDatasets reads the dataset registry file, or discovers the *.db files in dbRoot if there isn't one
+func LoadDatasets(dbRoot string) ([]Dataset, error) {
+	// use the registry file from the environment or the default one in the db root
+	configPath := os.Getenv("DATASETS_CONFIG")
+	if configPath == "" {
+		configPath = filepath.Join(dbRoot, DatasetsFileName)
+	}
+
+	var datasets []Dataset
+	raw, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		// parse the registry file
+		var file datasetsFile
+		if err := yaml.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", configPath, err)
+		}
+		datasets = file.Datasets
+	case errors.Is(err, fs.ErrNotExist):
+		// no registry file so mount every db in the root
+		datasets, err = discoverDatasets(dbRoot)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("read %s: %w", configPath, err)
+	}
+
+	// validate names and resolve each file against the db root
+	seen := make(map[string]bool, len(datasets))
+	for i := range datasets {
+		ds := &datasets[i]
+		if !datasetNamePattern.MatchString(ds.Name) {
+			return nil, fmt.Errorf("invalid dataset name %q", ds.Name)
+		}
+		if reservedDatasetNames[ds.Name] {
+			return nil, fmt.Errorf("dataset name %q is reserved", ds.Name)
+		}
+		if seen[ds.Name] {
+			return nil, fmt.Errorf("duplicate dataset name %q", ds.Name)
+		}
+		seen[ds.Name] = true
+
+		if ds.File == "" {
+			return nil, fmt.Errorf("dataset %q has no file", ds.Name)
+		}
+		ds.Path = ds.File
+		if !filepath.IsAbs(ds.Path) {
+			ds.Path = filepath.Join(dbRoot, ds.File)
+		}
+	}
+
+	return datasets, nil
+}
+
+// function to turn every *.db file in the root except the users db into a dataset named after the file
+func discoverDatasets(dbRoot string) ([]Dataset, error) {
+	matches, err := filepath.Glob(filepath.Join(dbRoot, "*.db"))
+	if err != nil {
+		return nil, fmt.Errorf("discover datasets: %w", err)
+	}
+	sort.Strings(matches)
+
+	datasets := make([]Dataset, 0, len(matches))
+	for _, match := range matches {
+		file := filepath.Base(match)
+		if file == UsersDBFileName {
+			continue
+		}
+
+		// students_gorm.db becomes students
+		name := strings.TrimSuffix(strings.TrimSuffix(file, ".db"), "_gorm")
+		datasets = append(datasets, Dataset{
+			Name: strings.ToLower(name),
+			File: file,
+		})
+	}
+
+	return datasets, nil
+}
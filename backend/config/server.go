@@ -0,0 +1,286 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// name of the server config file looked up in the db root when SERVER_CONFIG isn't set
+const ServerFileName = "server.yaml"
+
+// db roots used when neither DB_ROOT nor the config file name one, the first that exists wins.
+// a server that finds the production volume defaults to the production environment.
+const (
+	ProductionDBRoot  = "/vol/drafty3/backend/db"
+	DevelopmentDBRoot = "../db"
+)
+
+// defaults of the settings the config file and environment can change
+const (
+	defaultAddr                   = ":8081"
+	defaultSessionExpiry          = 20 * time.Minute
+	defaultSessionCleanupInterval = time.Hour
+	defaultShutdownTimeout        = 10 * time.Second
+)
+
+// origins allowed by default, the local astro dev server and the live site
+var defaultCORSOrigins = []string{
+	"http://localhost:4321",
+	"https://uri-hax.github.io",
+}
+
+// how often a session's end is moved forward, which a session has to outlast
+const minSessionExpiry = time.Minute
+
+// Server holds everything the backend server reads at startup
+type Server struct {
+	Environment string
+	// Addr is the address the server listens on, like :8081
+	Addr string
+	// ShutdownTimeout is how long in-flight requests get to finish once the server is told to stop
+	ShutdownTimeout time.Duration
+	CORSOrigins     []string
+	// DBRoot is the directory of the dataset dbs and the datasets and session config files
+	DBRoot string
+	// UsersDB is the path of the users db
+	UsersDB string
+	// Migrate is whether pending migrations are applied at startup, otherwise a db that needs them stops the server
+	Migrate bool
+	// SessionExpiry is how long a session lasts after its last request
+	SessionExpiry time.Duration
+	// SessionCleanupInterval is how often expired sessions are deleted from the users db
+	SessionCleanupInterval time.Duration
+	Session                Session
+}
+
+// model of the server config file, where paths are relative to the file and durations are like 20m
+type serverFile struct {
+	Environment     string   `yaml:"environment"`
+	Addr            string   `yaml:"addr"`
+	ShutdownTimeout string   `yaml:"shutdown_timeout"`
+	CORSOrigins     []string `yaml:"cors_origins"`
+	DB              struct {
+		Root    string `yaml:"root"`
+		Users   string `yaml:"users"`
+		Migrate *bool  `yaml:"migrate"`
+	} `yaml:"db"`
+	Sessions struct {
+		Expiry          string `yaml:"expiry"`
+		CleanupInterval string `yaml:"cleanup_interval"`
+	} `yaml:"sessions"`
+}
+
+// LoadServer reads the server config file and the environment variables, which win over the file,
+// then the session config of the db root. every setting is checked and all problems are returned together.
+func LoadServer() (Server, error) {
+	// use the config file from the environment or the default one in the db root
+	dbRoot := os.Getenv("DB_ROOT")
+	if dbRoot == "" {
+		dbRoot = defaultDBRoot()
+	}
+	configPath := os.Getenv("SERVER_CONFIG")
+	if configPath == "" {
+		configPath = filepath.Join(dbRoot, ServerFileName)
+	}
+
+	var file serverFile
+	raw, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(raw, &file); err != nil {
+			return Server{}, fmt.Errorf("parse %s: %w", configPath, err)
+		}
+		// paths in the file are relative to it
+		if file.DB.Root != "" && os.Getenv("DB_ROOT") == "" {
+			dbRoot = resolvePath(filepath.Dir(configPath), file.DB.Root)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		// every setting can come from the environment or the defaults
+	default:
+		return Server{}, fmt.Errorf("read %s: %w", configPath, err)
+	}
+
+	s := Server{
+		Environment: firstNonEmpty(os.Getenv("APP_ENV"), file.Environment),
+		Addr:        firstNonEmpty(os.Getenv("SERVER_ADDR"), file.Addr, defaultAddr),
+		CORSOrigins: file.CORSOrigins,
+		DBRoot:      dbRoot,
+	}
+	if s.Environment == "" {
+		s.Environment = EnvDevelopment
+		if dbRoot == ProductionDBRoot {
+			s.Environment = EnvProduction
+		}
+	}
+	if v := os.Getenv("CORS_ORIGINS"); v != "" {
+		s.CORSOrigins = splitList(v)
+	}
+	if len(s.CORSOrigins) == 0 {
+		s.CORSOrigins = defaultCORSOrigins
+	}
+
+	// the users db is next to the datasets unless it's moved, where DB_PATH_USERS is its directory
+	s.UsersDB = filepath.Join(dbRoot, UsersDBFileName)
+	if file.DB.Users != "" {
+		s.UsersDB = resolvePath(filepath.Dir(configPath), file.DB.Users)
+	}
+	if v := os.Getenv("DB_PATH_USERS"); v != "" {
+		s.UsersDB = filepath.Join(v, UsersDBFileName)
+	}
+
+	// check every setting so a bad deploy reports all of its mistakes at once
+	var problems []error
+	s.Migrate = true
+	if file.DB.Migrate != nil {
+		s.Migrate = *file.DB.Migrate
+	}
+	if v := os.Getenv("DB_MIGRATE"); v != "" {
+		if s.Migrate, err = strconv.ParseBool(v); err != nil {
+			problems = append(problems, fmt.Errorf("DB_MIGRATE %q must be true or false", v))
+		}
+	}
+	if s.Environment != EnvDevelopment && s.Environment != EnvProduction {
+		problems = append(problems, fmt.Errorf("environment %q must be %s or %s", s.Environment, EnvDevelopment, EnvProduction))
+	}
+	if _, _, err := net.SplitHostPort(s.Addr); err != nil {
+		problems = append(problems, fmt.Errorf("addr %q must be host:port or :port: %w", s.Addr, err))
+	}
+	for _, origin := range s.CORSOrigins {
+		if err := checkOrigin(origin); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if info, err := os.Stat(s.DBRoot); err != nil {
+		problems = append(problems, fmt.Errorf("db root: %w", err))
+	} else if !info.IsDir() {
+		problems = append(problems, fmt.Errorf("db root %s is not a directory", s.DBRoot))
+	}
+	if _, err := os.Stat(s.UsersDB); err != nil {
+		problems = append(problems, fmt.Errorf("users db: %w", err))
+	}
+
+	s.ShutdownTimeout, err = parseDuration("shutdown timeout",
+		firstNonEmpty(os.Getenv("SHUTDOWN_TIMEOUT"), file.ShutdownTimeout), defaultShutdownTimeout)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	s.SessionExpiry, err = parseDuration("session expiry",
+		firstNonEmpty(os.Getenv("SESSION_EXPIRY"), file.Sessions.Expiry), defaultSessionExpiry)
+	if err != nil {
+		problems = append(problems, err)
+	} else if s.SessionExpiry <= minSessionExpiry {
+		problems = append(problems, fmt.Errorf("session expiry must be longer than %s", minSessionExpiry))
+	}
+	s.SessionCleanupInterval, err = parseDuration("session cleanup interval",
+		firstNonEmpty(os.Getenv("SESSION_CLEANUP_INTERVAL"), file.Sessions.CleanupInterval), defaultSessionCleanupInterval)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	if len(problems) > 0 {
+		return Server{}, fmt.Errorf("invalid config %s: %w", configPath, errors.Join(problems...))
+	}
+
+	// the cookie lasts as long as a session unless the session config says otherwise
+	s.Session, err = LoadSession(s.DBRoot, s.Environment, s.SessionExpiry)
+	if err != nil {
+		return Server{}, err
+	}
+	return s, nil
+}
+
+// Redacted returns the config as it's shown to admins, with durations as text and the session keys hidden
+func (s Server) Redacted() map[string]interface{} {
+	keys := make([]string, len(s.Session.Keys))
+	for i := range keys {
+		keys[i] = "[redacted]"
+	}
+
+	return map[string]interface{}{
+		"environment":      s.Environment,
+		"addr":             s.Addr,
+		"shutdown_timeout": s.ShutdownTimeout.String(),
+		"cors_origins":     s.CORSOrigins,
+		"db": map[string]interface{}{
+			"root":    s.DBRoot,
+			"users":   s.UsersDB,
+			"migrate": s.Migrate,
+		},
+		"sessions": map[string]interface{}{
+			"expiry":           s.SessionExpiry.String(),
+			"cleanup_interval": s.SessionCleanupInterval.String(),
+			"keys":             keys,
+			"cookie": map[string]interface{}{
+				"secure":    s.Session.Secure,
+				"same_site": sameSiteName(s.Session.SameSite),
+				"max_age":   s.Session.MaxAge.String(),
+			},
+		},
+	}
+}
+
+// function to pick the first default db root that exists, or the development one if none do
+func defaultDBRoot() string {
+	for _, root := range []string{ProductionDBRoot, DevelopmentDBRoot} {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			return root
+		}
+	}
+	return DevelopmentDBRoot
+}
+
+// function to resolve a path from a config file against the file's directory
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// function to split a comma separated list, dropping blanks
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// function to check a CORS origin is a bare scheme and host, since browsers send them without a path
+func checkOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("cors origin %q must be like https://example.com", origin)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("cors origin %q can't have a path", origin)
+	}
+	return nil
+}
+
+// function to parse a positive duration, returning def if v is empty
+func parseDuration(name, v string, def time.Duration) (time.Duration, error) {
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// name of the session config file looked up in the db root when SESSION_CONFIG isn't set
+const SessionFileName = "session.yaml"
+
+// environments the cookie defaults are picked for
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Session holds the cookie signing and encryption keys and the cookie options
+type Session struct {
+	Environment string
+	// Keys are newest first, the first signs new cookies and every one is tried when reading a cookie
+	Keys     []SessionKey
+	Secure   bool
+	SameSite http.SameSite
+	MaxAge   time.Duration
+}
+
+// SessionKey is a securecookie key pair, where Block is optional and turns on encryption
+type SessionKey struct {
+	Hash  []byte
+	Block []byte
+}
+
+// model of the session config file, where keys are base64 and max_age is a duration like 20m
+type sessionFile struct {
+	Keys []struct {
+		Hash  string `yaml:"hash"`
+		Block string `yaml:"block"`
+	} `yaml:"keys"`
+	Cookie struct {
+		Secure   *bool  `yaml:"secure"`
+		SameSite string `yaml:"same_site"`
+		MaxAge   string `yaml:"max_age"`
+	} `yaml:"cookie"`
+}
+
+// LoadSession reads the session config file and the SESSION_* environment variables, which win over the file.
+// env picks the cookie defaults and the cookie lasts defaultMaxAge unless max_age is set. production needs
+// at least one key, development makes a throwaway one so cookies only last until the server restarts.
+func LoadSession(dbRoot, env string, defaultMaxAge time.Duration) (Session, error) {
+	// use the config file from the environment or the default one in the db root
+	configPath := os.Getenv("SESSION_CONFIG")
+	if configPath == "" {
+		configPath = filepath.Join(dbRoot, SessionFileName)
+	}
+
+	var file sessionFile
+	raw, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(raw, &file); err != nil {
+			return Session{}, fmt.Errorf("parse %s: %w", configPath, err)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		// every setting can come from the environment or the defaults
+	default:
+		return Session{}, fmt.Errorf("read %s: %w", configPath, err)
+	}
+
+	// the environment picks the cookie defaults
+	if env != EnvDevelopment && env != EnvProduction {
+		return Session{}, fmt.Errorf("unknown environment %q", env)
+	}
+	s := Session{
+		Environment: env,
+		Secure:      env == EnvProduction,
+		SameSite:    http.SameSiteLaxMode,
+		MaxAge:      defaultMaxAge,
+	}
+	// the production frontend is on another site so its cookie has to be sent cross site
+	if env == EnvProduction {
+		s.SameSite = http.SameSiteNoneMode
+	}
+
+	// keys from the environment replace the ones in the file
+	if rawKeys := os.Getenv("SESSION_KEYS"); rawKeys != "" {
+		for _, pair := range strings.Split(rawKeys, ",") {
+			hash, block, _ := strings.Cut(strings.TrimSpace(pair), ":")
+			key, err := decodeSessionKey(hash, block)
+			if err != nil {
+				return Session{}, fmt.Errorf("SESSION_KEYS: %w", err)
+			}
+			s.Keys = append(s.Keys, key)
+		}
+	} else {
+		for i, k := range file.Keys {
+			key, err := decodeSessionKey(k.Hash, k.Block)
+			if err != nil {
+				return Session{}, fmt.Errorf("%s key %d: %w", configPath, i+1, err)
+			}
+			s.Keys = append(s.Keys, key)
+		}
+	}
+
+	// cookie options from the file then the environment
+	if file.Cookie.Secure != nil {
+		s.Secure = *file.Cookie.Secure
+	}
+	if v := os.Getenv("SESSION_COOKIE_SECURE"); v != "" {
+		if s.Secure, err = strconv.ParseBool(v); err != nil {
+			return Session{}, fmt.Errorf("SESSION_COOKIE_SECURE: %w", err)
+		}
+	}
+	if v := firstNonEmpty(os.Getenv("SESSION_COOKIE_SAMESITE"), file.Cookie.SameSite); v != "" {
+		if s.SameSite, err = parseSameSite(v); err != nil {
+			return Session{}, err
+		}
+	}
+	if v := firstNonEmpty(os.Getenv("SESSION_COOKIE_MAX_AGE"), file.Cookie.MaxAge); v != "" {
+		if s.MaxAge, err = time.ParseDuration(v); err != nil {
+			return Session{}, fmt.Errorf("invalid cookie max age %q: %w", v, err)
+		}
+		if s.MaxAge <= 0 {
+			return Session{}, fmt.Errorf("cookie max age must be positive")
+		}
+	}
+
+	// browsers drop SameSite=None cookies that aren't Secure
+	if s.SameSite == http.SameSiteNoneMode && !s.Secure {
+		return Session{}, errors.New("same_site none needs secure cookies")
+	}
+
+	// make sure there's a key to sign with
+	if len(s.Keys) == 0 {
+		if env == EnvProduction {
+			return Session{}, errors.New("no session keys configured, set SESSION_KEYS or keys in " + configPath)
+		}
+		hash := make([]byte, 32)
+		if _, err := rand.Read(hash); err != nil {
+			return Session{}, fmt.Errorf("generate session key: %w", err)
+		}
+		slog.Warn("no session keys configured, using a throwaway key until the server restarts")
+		s.Keys = []SessionKey{{Hash: hash}}
+	}
+
+	return s, nil
+}
+
+// KeyPairs returns the keys in the order sessions.NewCookieStore takes them
+func (s Session) KeyPairs() [][]byte {
+	pairs := make([][]byte, 0, 2*len(s.Keys))
+	for _, k := range s.Keys {
+		pairs = append(pairs, k.Hash, k.Block)
+	}
+	return pairs
+}
+
+// function to decode a base64 key pair and check the key lengths securecookie accepts
+func decodeSessionKey(hash, block string) (SessionKey, error) {
+	var key SessionKey
+	var err error
+	if key.Hash, err = base64.StdEncoding.DecodeString(hash); err != nil {
+		return key, fmt.Errorf("hash key is not base64: %w", err)
+	}
+	if len(key.Hash) < 32 {
+		return key, fmt.Errorf("hash key must be at least 32 bytes, got %d", len(key.Hash))
+	}
+
+	if block == "" {
+		return key, nil
+	}
+	if key.Block, err = base64.StdEncoding.DecodeString(block); err != nil {
+		return key, fmt.Errorf("block key is not base64: %w", err)
+	}
+	switch len(key.Block) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return key, fmt.Errorf("block key must be 16, 24 or 32 bytes, got %d", len(key.Block))
+	}
+}
+
+// function to parse a SameSite setting
+func parseSameSite(v string) (http.SameSite, error) {
+	switch strings.ToLower(v) {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	case "default":
+		return http.SameSiteDefaultMode, nil
+	default:
+		return 0, fmt.Errorf("invalid same_site %q, use lax, strict, none or default", v)
+	}
+}
+
+// function to name a SameSite setting the way parseSameSite reads it
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "lax"
+	case http.SameSiteStrictMode:
+		return "strict"
+	case http.SameSiteNoneMode:
+		return "none"
+	default:
+		return "default"
+	}
+}
+
+// function to return the first value that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
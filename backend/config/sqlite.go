@@ -0,0 +1,17 @@
+package config
+
+import "strings"
+
+// how long a connection waits for another writer's lock before giving up, in milliseconds
+const sqliteBusyTimeout = "5000"
+
+// SQLiteDSN returns the connection string for a sqlite db so that every transaction takes the write lock
+// when it begins. deferred transactions only take it at their first write, so two edits could both read
+// the same confidence before either wrote. concurrent writers wait on each other for the busy timeout instead.
+func SQLiteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_txlock=immediate&_busy_timeout=" + sqliteBusyTimeout
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"database/sql"
 	"encoding/csv"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -11,13 +10,16 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
-	"strings"
 
 	_ "modernc.org/sqlite"
+
+	"drafty3/columns"
+	"drafty3/history"
 )
 
 // model of suggestions table rows we'll be looking at
 type SuggestionRow struct {
+	IDSuggestion     int
 	IDUniqueID       int
 	IDSuggestionType int
 	Suggestion       string
@@ -25,15 +27,10 @@ type SuggestionRow struct {
 	Active           int
 }
 
-// model for building the csprofs csv
-type CSProfRecord struct {
-	IDUniqueID int
-	FullName   string
-	University string
-	JoinYear   string
-	SubField   string
-	Bachelors  string
-	Doctorate  string
+// model of the suggestion type columns we'll be exporting
+type ColumnType struct {
+	IDSuggestionType int
+	Name             string
 }
 
 // main function to read flags and error accordingly if issues and call run function for logic
@@ -41,7 +38,8 @@ func main() {
 	// get flags and parse them
 	dbPath := flag.String("db", "", "Path to SQLite database file")
 	outPath := flag.String("out", "", "Path to output CSV file")
-	csvType := flag.String("csv_type", "", "Type of CSV to generate")
+	csvType := flag.String("csv_type", "", "Dataset name of the CSV to generate, or history for the edit history")
+	usersDBPath := flag.String("users_db", "", "Path to the users SQLite database file, used by --csv_type history to resolve editors")
 	flag.Parse()
 
 	// make sure required flags are provided
@@ -56,13 +54,13 @@ func main() {
 	}
 
 	// call the run function for the logic
-	if err := run(*dbPath, *outPath, *csvType); err != nil {
+	if err := run(*dbPath, *usersDBPath, *outPath, *csvType); err != nil {
 		log.Fatalf("build_csv failed: %v", err)
 	}
 }
 
 // run function to open the db and call the appropriate csv builder based on flags
-func run(dbPath, outPath, csvType string) error {
+func run(dbPath, usersDBPath, outPath, csvType string) error {
 	// open the db and eventually close it
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
@@ -75,28 +73,98 @@ func run(dbPath, outPath, csvType string) error {
 		return fmt.Errorf("ping database: %w", err)
 	}
 
-	// call the appropriate csv builder based on csvType flag
-	switch csvType {
-	case "csprofs":
-		return buildCSProfsCSV(db, outPath)
-	default:
-		return fmt.Errorf("unsupported csv_type: %s", csvType)
+	// history is the edit history of the db, anything else is a dataset name since every dataset shares the same schema
+	if csvType == "history" {
+		return buildHistoryCSV(db, usersDBPath, outPath)
+	}
+	return buildDatasetCSV(db, outPath, csvType)
+}
+
+// buildHistoryCSV builds a csv file of every edit in the db, newest first
+func buildHistoryCSV(db *sql.DB, usersDBPath, outPath string) error {
+	// open the users db if given so editors resolve to the profile of their session
+	var usersDB *sql.DB
+	if usersDBPath != "" {
+		var err error
+		usersDB, err = sql.Open("sqlite", usersDBPath)
+		if err != nil {
+			return fmt.Errorf("open users database: %w", err)
+		}
+		defer usersDB.Close()
+
+		if err := usersDB.Ping(); err != nil {
+			return fmt.Errorf("ping users database: %w", err)
+		}
+	}
+
+	// get the whole history
+	entries, _, err := history.Query(db, usersDB, history.Filter{})
+	if err != nil {
+		return err
+	}
+
+	// create the output directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	// create the output csv file
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("create output csv: %w", err)
+	}
+	defer file.Close()
+
+	// set up the csv writer and write the header row
+	writer := csv.NewWriter(file)
+	if err := writer.Write(history.Header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	// write one row per history entry
+	for _, entry := range entries {
+		if err := writer.Write(entry.Record()); err != nil {
+			return fmt.Errorf("write row for idEdit=%d: %w", entry.IDEdit, err)
+		}
+	}
+
+	// flush the writer and check for errors
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("flush csv writer: %w", err)
 	}
+
+	// log success and return
+	log.Printf("Wrote history CSV to %s with %d rows", outPath, len(entries))
+	return nil
 }
 
-// buildCSProfsCSV builds a csv file for the csprofs dataset
-func buildCSProfsCSV(db *sql.DB, outPath string) error {
-	// set up the query to get active suggestions of the relevant types
+// buildDatasetCSV builds a csv file for a dataset with one column per active, public suggestion type
+func buildDatasetCSV(db *sql.DB, outPath, dataset string) error {
+	// get the columns to export from the SuggestionType table
+	cols, err := loadColumnTypes(db)
+	if err != nil {
+		return err
+	}
+
+	// map each column id to its position in the output row
+	columnIndex := make(map[int]int, len(cols))
+	for i, col := range cols {
+		columnIndex[col.IDSuggestionType] = i
+	}
+
+	// set up the query to get active suggestions of rows that aren't deleted
 	query := `
 		SELECT
-			idUniqueID,
-			idSuggestionType,
-			suggestion,
-			confidence,
-			active
-		FROM Suggestions
-		WHERE active = 1
-		  AND idSuggestionType IN (1, 2, 3, 5, 7, 9)
+			s.idSuggestion,
+			s.idUniqueID,
+			s.idSuggestionType,
+			s.suggestion,
+			s.confidence,
+			s.active
+		FROM Suggestions s
+		JOIN UniqueId u ON u.idUniqueID = s.idUniqueID
+		WHERE s.active = 1 AND u.active = 1
 	`
 
 	// get the rows after the query
@@ -113,6 +181,7 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 	for rows.Next() {
 		var r SuggestionRow
 		if err := rows.Scan(
+			&r.IDSuggestion,
 			&r.IDUniqueID,
 			&r.IDSuggestionType,
 			&r.Suggestion,
@@ -122,10 +191,17 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 			return fmt.Errorf("scan row: %w", err)
 		}
 
-		// call function to make the string key for the map
+		// skip suggestions for columns we aren't exporting
+		if _, ok := columnIndex[r.IDSuggestionType]; !ok {
+			continue
+		}
+
+		// call function to make the string key for the map and keep the highest confidence suggestion,
+		// breaking ties with the newest suggestion so the output doesn't depend on row order
 		key := makeKey(r.IDUniqueID, r.IDSuggestionType)
 		existing, found := best[key]
-		if !found || r.Confidence > existing.Confidence {
+		if !found || r.Confidence > existing.Confidence ||
+			(r.Confidence == existing.Confidence && r.IDSuggestion > existing.IDSuggestion) {
 			best[key] = r
 		}
 	}
@@ -135,36 +211,20 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 		return fmt.Errorf("iterate rows: %w", err)
 	}
 
-	// map to hold the final records keyed by idUniqueID
-	recordMap := make(map[int]*CSProfRecord)
+	// map to hold the final records keyed by idUniqueID with one value per column
+	recordMap := make(map[int][]string)
 
 	// go through the best suggestions and populate the recordMap
 	for _, row := range best {
 		// get or create the record for this idUniqueID
 		rec, found := recordMap[row.IDUniqueID]
 		if !found {
-			rec = &CSProfRecord{IDUniqueID: row.IDUniqueID}
+			rec = make([]string, len(cols))
 			recordMap[row.IDUniqueID] = rec
 		}
 
-		// normalize the suggestion value across string and string[]
-		value := normalizeSuggestion(row.Suggestion)
-
-		// populate the appropriate field based on idSuggestionType
-		switch row.IDSuggestionType {
-		case 1:
-			rec.FullName = value
-		case 2:
-			rec.University = value
-		case 3:
-			rec.Bachelors = value
-		case 5:
-			rec.Doctorate = value
-		case 7:
-			rec.JoinYear = value
-		case 9:
-			rec.SubField = value
-		}
+		// normalize the suggestion value across string and string[] and put it in its column
+		rec[columnIndex[row.IDSuggestionType]] = columns.NormalizeValue(row.Suggestion)
 	}
 
 	// create a sorted list of idUniqueIDs for consistent output order
@@ -190,15 +250,11 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	// set the header row
-	header := []string{
-		"idUniqueID",
-		"FullName",
-		"University",
-		"JoinYear",
-		"SubField",
-		"Bachelors",
-		"Doctorate",
+	// set the header row from the column names
+	header := make([]string, 0, len(cols)+1)
+	header = append(header, "idUniqueID")
+	for _, col := range cols {
+		header = append(header, col.Name)
 	}
 
 	// write the header row
@@ -208,16 +264,7 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 
 	// go through the sorted ids and write the corresponding records to the csv
 	for _, id := range ids {
-		rec := recordMap[id]
-		row := []string{
-			strconv.Itoa(rec.IDUniqueID),
-			rec.FullName,
-			rec.University,
-			rec.JoinYear,
-			rec.SubField,
-			rec.Bachelors,
-			rec.Doctorate,
-		}
+		row := append([]string{strconv.Itoa(id)}, recordMap[id]...)
 
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("write row for idUniqueID=%d: %w", id, err)
@@ -231,47 +278,49 @@ func buildCSProfsCSV(db *sql.DB, outPath string) error {
 	}
 
 	// log success and return
-	log.Printf("Wrote csprofs CSV to %s with %d rows", outPath, len(ids))
+	log.Printf("Wrote %s CSV to %s with %d rows", dataset, outPath, len(ids))
 	return nil
 }
 
-// makeKey creates a string key for the map based on idUniqueID and idSuggestionType
-func makeKey(idUniqueID, idSuggestionType int) string {
-	return fmt.Sprintf("%d:%d", idUniqueID, idSuggestionType)
-}
+// loadColumnTypes gets the active, public suggestion types in column order
+func loadColumnTypes(db *sql.DB) ([]ColumnType, error) {
+	// columns without a columnOrder go last in id order
+	query := `
+		SELECT
+			idSuggestionType,
+			COALESCE(name, '')
+		FROM SuggestionType
+		WHERE isActive = 1
+		  AND isPrivate = 0
+		ORDER BY columnOrder IS NULL, columnOrder, idSuggestionType
+	`
 
-// normalizeSuggestion takes a raw suggestion string and normalizes it by trimming whitespace and handling JSON array strings and quoted strings
-func normalizeSuggestion(raw string) string {
-	s := strings.TrimSpace(raw)
-	if s == "" {
-		return ""
+	// get the rows after the query
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("query SuggestionType: %w", err)
 	}
+	defer rows.Close()
 
-	// if it's a JSON array string keep it that way but trim whitespace from each element in the array
-	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
-		var arr []string
-		// try to unmarshal it as a JSON array of strings
-		if err := json.Unmarshal([]byte(s), &arr); err == nil {
-			// trim whitespace from each element in the array
-			for i := range arr {
-				arr[i] = strings.TrimSpace(arr[i])
-			}
-
-			// marshal it back to a JSON string to ensure consistent formatting 
-			normalized, err := json.Marshal(arr)
-			if err == nil {
-				return string(normalized)
-			}
+	// go through the rows and collect the columns
+	var cols []ColumnType
+	for rows.Next() {
+		var col ColumnType
+		if err := rows.Scan(&col.IDSuggestionType, &col.Name); err != nil {
+			return nil, fmt.Errorf("scan suggestion type: %w", err)
 		}
+		cols = append(cols, col)
 	}
 
-	// if it's a quoted JSON string then unwrap it
-	var single string
-	// try to unmarshal it as a JSON string
-	if err := json.Unmarshal([]byte(s), &single); err == nil {
-		return strings.TrimSpace(single)
+	// check for errors from iterating over rows
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate suggestion types: %w", err)
 	}
 
-	// otherwise just return the trimmed raw string
-	return s
-}
\ No newline at end of file
+	return cols, nil
+}
+
+// makeKey creates a string key for the map based on idUniqueID and idSuggestionType
+func makeKey(idUniqueID, idSuggestionType int) string {
+	return fmt.Sprintf("%d:%d", idUniqueID, idSuggestionType)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/go_migration/data_model"
+)
+
+// newFixtureDB creates a dataset db with two public columns, one private column and three rows
+func newFixtureDB(t *testing.T) (string, *gorm.DB) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "fixture.db")
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open fixture db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(
+		&data_model.SuggestionType{},
+		&data_model.UniqueId{},
+		&data_model.Suggestions{},
+	); err != nil {
+		t.Fatalf("migrate fixture db: %v", err)
+	}
+
+	name, city, notes := "Name", "City", "Notes"
+	first, second := int64(1), int64(2)
+	columns := []data_model.SuggestionType{
+		{IDSuggestionType: 1, IDDataType: 1, Name: &name, IsActive: 1, ColumnOrder: &first},
+		{IDSuggestionType: 2, IDDataType: 1, Name: &city, IsActive: 1, ColumnOrder: &second},
+		{IDSuggestionType: 3, IDDataType: 1, Name: &notes, IsActive: 1, IsPrivate: 1},
+	}
+	if err := db.Create(&columns).Error; err != nil {
+		t.Fatalf("seed columns: %v", err)
+	}
+
+	cells := map[int64][3]string{
+		1: {"Ada", "London", "a"},
+		2: {"Grace", "New York", "b"},
+		3: {"Alan", "Manchester", "c"},
+	}
+	for id := int64(1); id <= 3; id++ {
+		if err := db.Create(&data_model.UniqueId{IDUniqueID: id, Active: 1}).Error; err != nil {
+			t.Fatalf("seed row %d: %v", id, err)
+		}
+		for i, value := range cells[id] {
+			active, confidence := int64(1), int64(1)
+			s := data_model.Suggestions{
+				IDSuggestionType: int64(i + 1),
+				IDUniqueID:       id,
+				IDProfile:        1,
+				Suggestion:       value,
+				Active:           &active,
+				Confidence:       &confidence,
+			}
+			if err := db.Create(&s).Error; err != nil {
+				t.Fatalf("seed cell %d/%d: %v", id, i+1, err)
+			}
+		}
+	}
+
+	return dbPath, db
+}
+
+// deleteRow marks a row and every cell of it inactive, the way deleting a row does
+func deleteRow(t *testing.T, db *gorm.DB, id int64) {
+	t.Helper()
+
+	if err := db.Model(&data_model.UniqueId{}).Where("idUniqueID = ?", id).Update("active", 0).Error; err != nil {
+		t.Fatalf("delete row %d: %v", id, err)
+	}
+	if err := db.Model(&data_model.Suggestions{}).Where("idUniqueID = ?", id).Update("active", 0).Error; err != nil {
+		t.Fatalf("deactivate cells of row %d: %v", id, err)
+	}
+}
+
+// readCSV builds the dataset csv of the db and returns its records
+func readCSV(t *testing.T, dbPath string) [][]string {
+	t.Helper()
+
+	outPath := filepath.Join(t.TempDir(), "out.csv")
+	if err := run(dbPath, "", outPath, "fixture"); err != nil {
+		t.Fatalf("build csv: %v", err)
+	}
+
+	file, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("open csv: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	return records
+}
+
+func TestDeletedRowLeavesCSV(t *testing.T) {
+	dbPath, db := newFixtureDB(t)
+
+	if got := len(readCSV(t, dbPath)); got != 4 {
+		t.Fatalf("csv before delete has %d records, want header and 3 rows", got)
+	}
+
+	deleteRow(t, db, 2)
+
+	want := [][]string{
+		{"idUniqueID", "Name", "City"},
+		{"1", "Ada", "London"},
+		{"3", "Alan", "Manchester"},
+	}
+	got := readCSV(t, dbPath)
+	if len(got) != len(want) {
+		t.Fatalf("csv after delete = %v, want %v", got, want)
+	}
+	for i := range want {
+		if strings.Join(got[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("csv record %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestInactiveRowWithActiveCellsLeavesCSV(t *testing.T) {
+	dbPath, db := newFixtureDB(t)
+
+	// rows deleted before every cell was deactivated still have active cells
+	if err := db.Model(&data_model.UniqueId{}).
+		Where("idUniqueID = ?", 3).
+		Update("active", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, record := range readCSV(t, dbPath)[1:] {
+		if record[0] == "3" {
+			t.Fatalf("csv still has deleted row: %v", record)
+		}
+	}
+}
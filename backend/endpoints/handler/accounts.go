@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/user_model"
+)
+
+// ACCOUNTS HANDLER
+
+// password length limits, where bcrypt ignores anything past 72 bytes
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// usernames are shown next to edits so keep them plain
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// AccountsHandler holds the users DB
+type AccountsHandler struct {
+	DB *gorm.DB
+	// SessionExpiration is how long the sessions started on signup and login last
+	SessionExpiration time.Duration
+}
+
+// NewAccountsHandler returns a new AccountsHandler for the given users DB and session expiration
+func NewAccountsHandler(db *gorm.DB, sessionExpiration time.Duration) *AccountsHandler {
+	return &AccountsHandler{DB: db, SessionExpiration: sessionExpiration}
+}
+
+// accountProfile is a profile as the account itself sees it, with the email other views of it leave out
+type accountProfile struct {
+	user_model.Profile
+	Email *string `json:"Email"`
+}
+
+// function to show a profile to its own account
+func ownProfile(profile user_model.Profile) accountProfile {
+	return accountProfile{Profile: profile, Email: profile.Email}
+}
+
+// struct of what we expect from front end to sign up
+type signupPayload struct {
+	Username string `json:"Username"`
+	Email    string `json:"Email"`
+	Password string `json:"Password"`
+}
+
+// struct of what we expect from front end to log in, where Username can also be the email
+type loginPayload struct {
+	Username string `json:"Username"`
+	Password string `json:"Password"`
+}
+
+// Signup handles POST /api/users/signup
+func (h *AccountsHandler) Signup(c echo.Context) error {
+	// read the cookie based session from middleware
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to read cookie session",
+		})
+	}
+
+	// bind request JSON and check the account details
+	var payload signupPayload
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+	username, email, err := checkSignup(payload)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid account details",
+			"detail": err.Error(),
+		})
+	}
+
+	// hash the password
+	hash, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to hash password",
+			"detail": err.Error(),
+		})
+	}
+	password := string(hash)
+
+	// set up data models
+	var profile user_model.Profile
+	var session user_model.Session
+	now := time.Now()
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// usernames and emails can only be used once, ignoring case
+		var taken int64
+		query := tx.Model(&user_model.Profile{}).Where("LOWER(username) = LOWER(?)", username)
+		if email != nil {
+			query = query.Or("LOWER(email) = LOWER(?)", *email)
+		}
+		if err := query.Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return echo.NewHTTPError(http.StatusConflict, "username or email is already taken")
+		}
+
+		// the anonymous profile in the cookie becomes the account so its edits and sessions stay with it
+		if profileID, ok := getInt64(cookieSession.Values["profile_id"]); ok && profileID != 0 {
+			err := tx.First(&profile, "idProfile = ?", profileID).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil && profile.Username != nil {
+				return echo.NewHTTPError(http.StatusConflict, "log out before signing up for another account")
+			}
+			if err != nil {
+				profile = user_model.Profile{}
+			}
+		}
+
+		// fill in the account and create the profile if the cookie didn't have one
+		profile.Username = &username
+		profile.Email = email
+		profile.Password = &password
+		profile.DateUpdated = now
+		if err := tx.Save(&profile).Error; err != nil {
+			return err
+		}
+
+		// keep the current session if it belongs to the profile, otherwise start one
+		session, err = currentOrNewSession(tx, cookieSession.Values["id_session"], profile.IDProfile, now, h.SessionExpiration)
+		return err
+	})
+
+	// error handling for the transaction
+	if err != nil {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return c.JSON(httpErr.Code, echo.Map{
+				"error": httpErr.Message,
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to sign up",
+			"detail": err.Error(),
+		})
+	}
+
+	// save identifiers in cookie
+	if err := saveAccountCookie(c, h.DB, profile.IDProfile, session.IDSession); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to save cookie session",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"profile": ownProfile(profile),
+		"session": session,
+	})
+}
+
+// Login handles POST /api/users/login
+func (h *AccountsHandler) Login(c echo.Context) error {
+	// read the cookie based session from middleware
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to read cookie session",
+		})
+	}
+
+	// bind request JSON
+	var payload loginPayload
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+
+	// find the account by username or email and check the password, giving the same answer for either mistake
+	var profile user_model.Profile
+	login := strings.TrimSpace(payload.Username)
+	err = h.DB.
+		Where("LOWER(username) = LOWER(?) OR LOWER(email) = LOWER(?)", login, login).
+		First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to find account",
+			"detail": err.Error(),
+		})
+	}
+	if err != nil || profile.Password == nil ||
+		bcrypt.CompareHashAndPassword([]byte(*profile.Password), []byte(payload.Password)) != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "invalid username or password",
+		})
+	}
+
+	// the anonymous profile in the cookie is left as it is, since whoever used the browser before may not
+	// be the account's owner. keep the current session if it belongs to the account, otherwise start one
+	session, err := currentOrNewSession(h.DB, cookieSession.Values["id_session"], profile.IDProfile, time.Now(), h.SessionExpiration)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to create session",
+			"detail": err.Error(),
+		})
+	}
+
+	// save identifiers in cookie
+	if err := saveAccountCookie(c, h.DB, profile.IDProfile, session.IDSession); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to save cookie session",
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"profile": ownProfile(profile),
+		"session": session,
+	})
+}
+
+// Logout handles POST /api/users/logout
+func (h *AccountsHandler) Logout(c echo.Context) error {
+	// read the cookie based session from middleware
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to read cookie session",
+		})
+	}
+
+	// end the current session if there is one
+	if sessionID, ok := getInt64(cookieSession.Values["id_session"]); ok && sessionID != 0 {
+		var existing user_model.Session
+		err := h.DB.First(&existing, "idSession = ?", sessionID).Error
+		if err == nil {
+			err = endSession(h.DB, &existing, time.Now())
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error":  "failed to end session",
+				"detail": err.Error(),
+			})
+		}
+	}
+
+	// delete the stored session and its cookie so the next visit starts a new anonymous profile
+	cookieSession.Options.MaxAge = -1
+	if err := cookieSession.Save(c.Request(), c.Response()); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to save cookie session",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// function to check the signup details, returning the username and the email or nil if none was given
+func checkSignup(payload signupPayload) (string, *string, error) {
+	username := strings.TrimSpace(payload.Username)
+	if !usernamePattern.MatchString(username) {
+		return "", nil, errors.New("username must be 3 to 32 letters, numbers, dots, dashes or underscores")
+	}
+
+	var email *string
+	if e := strings.TrimSpace(payload.Email); e != "" {
+		addr, err := mail.ParseAddress(e)
+		if err != nil || addr.Address != e {
+			return "", nil, errors.New("email is not a valid address")
+		}
+		lower := strings.ToLower(e)
+		email = &lower
+	}
+
+	if len(payload.Password) < minPasswordLength || len(payload.Password) > maxPasswordLength {
+		return "", nil, fmt.Errorf("password must be %d to %d characters", minPasswordLength, maxPasswordLength)
+	}
+
+	return username, email, nil
+}
+
+// function to get the cookie's session if it belongs to the profile and hasn't ended, or start a new one
+func currentOrNewSession(db *gorm.DB, cookieValue interface{}, profileID int64, now time.Time, expiration time.Duration) (user_model.Session, error) {
+	if sessionID, ok := getInt64(cookieValue); ok && sessionID != 0 {
+		var existing user_model.Session
+		err := db.First(&existing, "idSession = ?", sessionID).Error
+		if err == nil && existing.IDProfile == profileID && now.Before(existing.End) {
+			return existing, nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return existing, err
+		}
+
+		// the session belongs to whoever used the browser before, so it ends here
+		if err == nil && existing.IDProfile != profileID {
+			if err := endSession(db, &existing, now); err != nil {
+				return existing, err
+			}
+		}
+	}
+
+	return startSession(db, profileID, now, expiration)
+}
+
+// function to save the account's profile and session in the cookie under a new stored session id, deleting the
+// stored session of the cookie it had so an id fixed on the anonymous cookie can't be used to act as the account
+func saveAccountCookie(c echo.Context, db *gorm.DB, profileID, sessionID int64) error {
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return err
+	}
+	if cookieSession.ID != "" {
+		if err := db.Delete(&user_model.Sessions{}, "session_id = ?", cookieSession.ID).Error; err != nil {
+			return err
+		}
+		cookieSession.ID = ""
+	}
+	cookieSession.Values["profile_id"] = profileID
+	cookieSession.Values["id_session"] = sessionID
+	return cookieSession.Save(c.Request(), c.Response())
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/config"
+	"drafty3/go_migration/user_model"
+	"drafty3/migrations"
+	"drafty3/sessionstore"
+)
+
+func TestSignupAndLoginRotateTheStoredSession(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(filepath.Join(t.TempDir(), "users.db"))), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db, migrations.Users, 0); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	store := sessionstore.New(db, securecookie.GenerateRandomKey(32))
+	accounts := NewAccountsHandler(db, 20*time.Minute)
+
+	e := echo.New()
+	e.Use(esession.Middleware(store))
+	// an anonymous visit, which is where an attacker would fix the stored session id
+	e.POST("/anonymous", func(c echo.Context) error {
+		profile := user_model.Profile{}
+		if err := db.Create(&profile).Error; err != nil {
+			return err
+		}
+		sess, _ := esession.Get("session", c)
+		sess.Values["profile_id"] = profile.IDProfile
+		if err := sess.Save(c.Request(), c.Response()); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.POST("/signup", accounts.Signup)
+	e.POST("/login", accounts.Login)
+
+	// post sends a request with cookie, checks its status and returns the cookie it set
+	post := func(path, body string, cookie *http.Cookie, wantStatus int) *http.Cookie {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != wantStatus {
+			t.Fatalf("%s: status %d, body %s", path, rec.Code, rec.Body.String())
+		}
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "session" {
+				return c
+			}
+		}
+		t.Fatalf("%s set no session cookie", path)
+		return nil
+	}
+	// storedID returns the stored session id a cookie carries
+	storedID := func(cookie *http.Cookie) string {
+		t.Helper()
+		var id string
+		if err := securecookie.DecodeMulti("session", cookie.Value, &id, store.Codecs...); err != nil {
+			t.Fatalf("decode cookie: %v", err)
+		}
+		return id
+	}
+	// checkRotated fails unless the new cookie has a new id and the old one's row is gone
+	checkRotated := func(step string, before, after *http.Cookie) {
+		t.Helper()
+		if storedID(after) == storedID(before) {
+			t.Errorf("%s kept stored session %s", step, storedID(before))
+		}
+		var rows int64
+		if err := db.Model(&user_model.Sessions{}).Where("session_id = ?", storedID(before)).Count(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if rows != 0 {
+			t.Errorf("%s left the stored session %s it replaced", step, storedID(before))
+		}
+	}
+
+	anonymous := post("/anonymous", "", nil, http.StatusNoContent)
+	signedUp := post("/signup", `{"Username":"ada","Password":"correct horse"}`, anonymous, http.StatusCreated)
+	checkRotated("signup", anonymous, signedUp)
+	loggedIn := post("/login", `{"Username":"ada","Password":"correct horse"}`, signedUp, http.StatusOK)
+	checkRotated("login", signedUp, loggedIn)
+}
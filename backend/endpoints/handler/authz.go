@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/user_model"
+)
+
+// AUTHORIZATION
+
+// names of the roles in the users db Role table
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// RoutePolicies maps "METHOD /path" of a route, relative to the group it's registered in,
+// to the roles allowed to call it. routes that aren't listed are open to everyone.
+type RoutePolicies map[string][]string
+
+// Authorizer resolves the caller's role from the profile in the session cookie
+type Authorizer struct {
+	DB       *gorm.DB
+	Policies RoutePolicies
+}
+
+// NewAuthorizer returns a new Authorizer for the given users DB and policies
+func NewAuthorizer(db *gorm.DB, policies RoutePolicies) *Authorizer {
+	return &Authorizer{DB: db, Policies: policies}
+}
+
+// Authorize is middleware for a group mounted at prefix that checks the caller's role against the route's policy
+func (a *Authorizer) Authorize(prefix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// find the policy of the matched route
+			route := c.Request().Method + " " + strings.TrimPrefix(c.Path(), prefix)
+			allowed, ok := a.Policies[route]
+			if !ok {
+				return next(c)
+			}
+
+			// restricted routes need a logged in account
+			profile, err := a.cookieAccount(c)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"error":  "failed to check role",
+					"detail": err.Error(),
+				})
+			}
+			if profile == nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"error": "log in required",
+				})
+			}
+
+			// look up the account's role and check it's one of the allowed ones
+			var role user_model.Role
+			if err := a.DB.First(&role, "idRole = ?", profile.IDRole).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"error":  "failed to check role",
+					"detail": err.Error(),
+				})
+			}
+			for _, r := range allowed {
+				if role.Role == r {
+					return next(c)
+				}
+			}
+
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"error":  "forbidden",
+				"detail": "requires one of the roles: " + strings.Join(allowed, ", "),
+			})
+		}
+	}
+}
+
+// function to get the account profile in the session cookie, or nil if it has none or it's anonymous
+func (a *Authorizer) cookieAccount(c echo.Context) (*user_model.Profile, error) {
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return nil, err
+	}
+	profileID, ok := getInt64(cookieSession.Values["profile_id"])
+	if !ok || profileID == 0 {
+		return nil, nil
+	}
+
+	var profile user_model.Profile
+	if err := a.DB.First(&profile, "idProfile = ?", profileID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if profile.Username == nil {
+		return nil, nil
+	}
+	return &profile, nil
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"drafty3/config"
+)
+
+// CONFIG HANDLER
+
+// ConfigHandler holds the config the server started with
+type ConfigHandler struct {
+	Config config.Server
+}
+
+// NewConfigHandler returns a new ConfigHandler for the given config
+func NewConfigHandler(cfg config.Server) *ConfigHandler {
+	return &ConfigHandler{Config: cfg}
+}
+
+// GetConfig handles GET /api/admin/config
+func (h *ConfigHandler) GetConfig(c echo.Context) error {
+	// return the config without the session keys
+	return c.JSON(http.StatusOK, h.Config.Redacted())
+}
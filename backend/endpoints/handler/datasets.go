@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+)
+
+// DATASETS HANDLER
+
+// Dataset is a mounted dataset db and the name it's served under
+type Dataset struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// DatasetsHandler holds every mounted dataset
+type DatasetsHandler struct {
+	Datasets []Dataset
+}
+
+// NewDatasetsHandler returns a new DatasetsHandler for the given datasets
+func NewDatasetsHandler(datasets []Dataset) *DatasetsHandler {
+	return &DatasetsHandler{Datasets: datasets}
+}
+
+// column metadata returned for each dataset
+type datasetColumn struct {
+	IDSuggestionType int64  `json:"idSuggestionType"`
+	Name             string `json:"name"`
+	IDDataType       int64  `json:"idDataType"`
+	ColumnOrder      *int64 `json:"columnOrder"`
+	IsEditable       int64  `json:"isEditable"`
+}
+
+// GetDatasets handles GET /api/datasets
+func (h *DatasetsHandler) GetDatasets(c echo.Context) error {
+	datasets := make([]echo.Map, 0, len(h.Datasets))
+
+	for _, ds := range h.Datasets {
+		// load the active, public columns in display order
+		var sts []data_model.SuggestionType
+		if err := ds.DB.
+			Where("isActive = 1 AND isPrivate = 0").
+			Order(columnOrderClause).
+			Find(&sts).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error":   "failed to fetch suggestion types",
+				"dataset": ds.Name,
+				"detail":  err.Error(),
+			})
+		}
+
+		// trim each suggestion type down to its column metadata
+		columns := make([]datasetColumn, 0, len(sts))
+		for _, st := range sts {
+			name := ""
+			if st.Name != nil {
+				name = *st.Name
+			}
+			columns = append(columns, datasetColumn{
+				IDSuggestionType: st.IDSuggestionType,
+				Name:             name,
+				IDDataType:       st.IDDataType,
+				ColumnOrder:      st.ColumnOrder,
+				IsEditable:       st.IsEditable,
+			})
+		}
+
+		datasets = append(datasets, echo.Map{
+			"name":    ds.Name,
+			"columns": columns,
+		})
+	}
+
+	// return every mounted dataset
+	return c.JSON(http.StatusOK, datasets)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"drafty3/go_migration/data_model"
+)
+
+func TestDeleteRowDeactivatesEveryCell(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name", "City", "Notes"},
+		[]string{"Ada", "London", "a"},
+		[]string{"Grace", "New York", "b"},
+	)
+	delRows := NewEditDelRowHandler(db)
+	body := `{"IDUniqueID":2,"IDInteractionType":1,"IDEntryType":3,"Comment":"duplicate"}`
+
+	rec := serveWithSession(delRows.CreateEditDelRow, http.MethodPost, "/api/editdelrows", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("delete row: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// the row and every cell of it are inactive, and the other row is untouched
+	var row data_model.UniqueId
+	if err := db.First(&row, "idUniqueID = ?", 2).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.Active != 0 {
+		t.Errorf("deleted row is still active")
+	}
+	for rowID, want := range map[int64]int64{1: 3, 2: 0} {
+		var active int64
+		if err := db.Model(&data_model.Suggestions{}).
+			Where("idUniqueID = ? AND active = 1", rowID).
+			Count(&active).Error; err != nil {
+			t.Fatal(err)
+		}
+		if active != want {
+			t.Errorf("row %d has %d active cells, want %d", rowID, active, want)
+		}
+	}
+
+	// deleting it again is a conflict and doesn't record another edit
+	rec = serveWithSession(delRows.CreateEditDelRow, http.MethodPost, "/api/editdelrows", body)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("second delete: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+	var edits int64
+	if err := db.Model(&data_model.EditDelRow{}).Count(&edits).Error; err != nil {
+		t.Fatal(err)
+	}
+	if edits != 1 {
+		t.Errorf("got %d del row edits, want 1", edits)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"drafty3/go_migration/data_model"
+)
+
+func TestConcurrentEditsLeaveOneActiveSuggestion(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	edits := NewEditHandler(db)
+
+	// fire parallel edits at the same cell
+	const n = 20
+	var wg sync.WaitGroup
+	codes := make([]int, n)
+	bodies := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"IDInteractionType":1,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+				`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":"value %d","Active":1}`, i)
+			rec := serveWithSession(edits.CreateEdit, http.MethodPost, "/api/edits", body)
+			codes[i], bodies[i] = rec.Code, rec.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range codes {
+		if codes[i] != http.StatusCreated {
+			t.Errorf("edit %d: status %d, body %s", i, codes[i], bodies[i])
+		}
+	}
+
+	// exactly one suggestion of the cell is active and it's the one with the highest confidence
+	var suggestions []data_model.Suggestions
+	if err := db.
+		Where("idSuggestionType = 1 AND idUniqueID = 1").
+		Order("confidence DESC").
+		Find(&suggestions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != n+1 {
+		t.Fatalf("got %d suggestions, want %d", len(suggestions), n+1)
+	}
+
+	activeCount := 0
+	seen := make(map[int64]bool)
+	for _, s := range suggestions {
+		if *s.Active == 1 {
+			activeCount++
+		}
+		if seen[*s.Confidence] {
+			t.Errorf("confidence %d was given to more than one suggestion", *s.Confidence)
+		}
+		seen[*s.Confidence] = true
+	}
+	if activeCount != 1 {
+		t.Errorf("got %d active suggestions, want 1", activeCount)
+	}
+	if *suggestions[0].Active != 1 {
+		t.Errorf("active suggestion is not the highest confidence one")
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/config"
+	"drafty3/go_migration/data_model"
+	"drafty3/migrations"
+)
+
+// newDatasetDB creates a dataset db on disk, opened and migrated the way the server does, with a column for
+// each name in display order and a row for each set of cells, numbered from 1 and written by profile 1
+func newDatasetDB(t *testing.T, columns []string, rows ...[]string) *gorm.DB {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "dataset.db")
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(dbPath)), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db, migrations.Dataset, 0); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	for i, name := range columns {
+		name, order := name, int64(i+1)
+		if err := db.Create(&data_model.SuggestionType{
+			IDSuggestionType: order,
+			IDDataType:       1,
+			Name:             &name,
+			IsActive:         1,
+			ColumnOrder:      &order,
+		}).Error; err != nil {
+			t.Fatalf("seed column %s: %v", name, err)
+		}
+	}
+
+	for i, cells := range rows {
+		rowID := int64(i + 1)
+		if err := db.Create(&data_model.UniqueId{IDUniqueID: rowID, Active: 1}).Error; err != nil {
+			t.Fatalf("seed row %d: %v", rowID, err)
+		}
+		for j, value := range cells {
+			active, confidence := int64(1), int64(1)
+			if err := db.Create(&data_model.Suggestions{
+				IDSuggestionType: int64(j + 1),
+				IDUniqueID:       rowID,
+				IDProfile:        1,
+				Suggestion:       value,
+				Active:           &active,
+				Confidence:       &confidence,
+			}).Error; err != nil {
+				t.Fatalf("seed cell %d/%d: %v", rowID, j+1, err)
+			}
+		}
+	}
+
+	return db
+}
+
+// serveWithSession serves a request to a handler with a session cookie for session and profile 1
+func serveWithSession(h echo.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	return serveRouteWithSession(h, method, target, target, body)
+}
+
+// serveRouteWithSession serves a request for target to a handler registered at a route with path params
+func serveRouteWithSession(h echo.HandlerFunc, method, route, target, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Use(esession.Middleware(sessions.NewCookieStore([]byte("test"))))
+	e.Add(method, route, func(c echo.Context) error {
+		sess, _ := esession.Get("session", c)
+		sess.Values["id_session"] = int64(1)
+		sess.Values["profile_id"] = int64(1)
+		return h(c)
+	})
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
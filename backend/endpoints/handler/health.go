@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+)
+
+// HEALTH HANDLER
+
+// how long all the checks of one request get, which covers a write lock waiting out the busy timeout
+const healthTimeout = 10 * time.Second
+
+// free space the volume of a db needs, below which sqlite can fail to grow the db or its journal
+const minFreeDiskBytes = 256 << 20
+
+// how long a db's integrity and write lock results are reused, since every page load checks health and
+// those checks read the whole db and wait for the write lock
+const deepHealthTTL = 10 * time.Second
+
+// returned by freeDiskBytes where the platform can't tell, which leaves the free space unknown
+var errFreeDiskUnknown = errors.New("free disk space is not available on this platform")
+
+// integrity and write lock results of a db and when they were checked
+type deepHealth struct {
+	at     time.Time
+	checks map[string]healthCheck
+}
+
+// last integrity and write lock results of each db, shared by the health handlers since they check the same dbs
+var (
+	deepHealthMu    sync.Mutex
+	deepHealthCache = make(map[*gorm.DB]deepHealth)
+)
+
+// HealthHandler holds the users DB and the dataset DBs whose health is checked
+type HealthHandler struct {
+	UsersDB  *gorm.DB
+	Datasets []Dataset
+}
+
+// NewHealthHandler returns a new HealthHandler for the given users DB and datasets
+func NewHealthHandler(usersDB *gorm.DB, datasets []Dataset) *HealthHandler {
+	return &HealthHandler{UsersDB: usersDB, Datasets: datasets}
+}
+
+// result of one check, with what it found
+type healthCheck struct {
+	OK    bool        `json:"ok"`
+	Value interface{} `json:"value,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// checks of one db, which is healthy if every check is
+type dbHealth struct {
+	Name   string                 `json:"name"`
+	OK     bool                   `json:"ok"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// GetHealth handles GET /api/health and GET /api/<name>/health, returning 503 if any db fails a check so a
+// caller can tell a broken db from a server that doesn't answer
+func (h *HealthHandler) GetHealth(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), healthTimeout)
+	defer cancel()
+
+	// check the users db, then every dataset db and its interactions
+	dbs := []dbHealth{checkDBHealth(ctx, "users", h.UsersDB, false)}
+	for _, ds := range h.Datasets {
+		dbs = append(dbs, checkDBHealth(ctx, ds.Name, ds.DB, true))
+	}
+
+	for _, db := range dbs {
+		if !db.OK {
+			return c.JSON(http.StatusServiceUnavailable, echo.Map{
+				"status":    "unhealthy",
+				"databases": dbs,
+			})
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"status":    "healthy",
+		"databases": dbs,
+	})
+}
+
+// function to run every check against a db, where a failed check doesn't stop the ones after it
+func checkDBHealth(ctx context.Context, name string, db *gorm.DB, interactions bool) dbHealth {
+	deep := checkDeepHealth(db)
+	db = db.WithContext(ctx)
+	checks := map[string]healthCheck{
+		"ping":         checkPing(ctx, db),
+		"query":        checkQuery(db),
+		"integrity":    deep["integrity"],
+		"journal_mode": checkJournalMode(db),
+		"write_lock":   deep["write_lock"],
+		"disk":         checkDisk(db),
+	}
+	if interactions {
+		checks["last_interaction"] = checkLastInteraction(db)
+	}
+
+	health := dbHealth{Name: name, OK: true, Checks: checks}
+	for _, check := range checks {
+		health.OK = health.OK && check.OK
+	}
+	return health
+}
+
+// function to run the integrity and write lock checks of a db, or reuse their results if they ran in the last
+// deepHealthTTL. requests wait for a check that's running rather than start their own, and the checks don't
+// run on a request's context so one that goes away can't leave a failure behind for the others.
+func checkDeepHealth(db *gorm.DB) map[string]healthCheck {
+	deepHealthMu.Lock()
+	defer deepHealthMu.Unlock()
+
+	if cached, ok := deepHealthCache[db]; ok && time.Since(cached.at) < deepHealthTTL {
+		return cached.checks
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+	checks := map[string]healthCheck{
+		"integrity":  checkIntegrity(db.WithContext(ctx)),
+		"write_lock": checkWriteLock(db.WithContext(ctx)),
+	}
+	deepHealthCache[db] = deepHealth{at: time.Now(), checks: checks}
+	return checks
+}
+
+// function to turn a check's error into its result
+func failedCheck(err error) healthCheck {
+	return healthCheck{Error: err.Error()}
+}
+
+// function to check a connection to the db can be made
+func checkPing(ctx context.Context, db *gorm.DB) healthCheck {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return failedCheck(err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return failedCheck(err)
+	}
+	return healthCheck{OK: true}
+}
+
+// function to check the db answers a trivial query
+func checkQuery(db *gorm.DB) healthCheck {
+	var one int
+	if err := db.Raw("SELECT 1").Scan(&one).Error; err != nil {
+		return failedCheck(err)
+	}
+	return healthCheck{OK: true}
+}
+
+// function to run sqlite's quick integrity check, which reports "ok" or the problems it found
+func checkIntegrity(db *gorm.DB) healthCheck {
+	var results []string
+	if err := db.Raw("PRAGMA quick_check").Scan(&results).Error; err != nil {
+		return failedCheck(err)
+	}
+	if len(results) != 1 || results[0] != "ok" {
+		return healthCheck{Value: results, Error: "integrity check found problems"}
+	}
+	return healthCheck{OK: true, Value: results[0]}
+}
+
+// function to report the journal mode, which differs between dev and production dbs so isn't judged
+func checkJournalMode(db *gorm.DB) healthCheck {
+	var mode string
+	if err := db.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil {
+		return failedCheck(err)
+	}
+	return healthCheck{OK: true, Value: mode}
+}
+
+// function to take and release the write lock, which fails if the db is read-only or another writer
+// held it past the busy timeout
+func checkWriteLock(db *gorm.DB) healthCheck {
+	// transactions begin immediate so beginning one takes the lock
+	tx := db.Begin()
+	if tx.Error != nil {
+		return failedCheck(tx.Error)
+	}
+	if err := tx.Rollback().Error; err != nil {
+		return failedCheck(err)
+	}
+	return healthCheck{OK: true}
+}
+
+// function to check the db file still exists and its volume has room for it to grow
+func checkDisk(db *gorm.DB) healthCheck {
+	// sqlite knows the file it opened, which is empty for an in-memory db
+	var databases []struct {
+		Name string
+		File string
+	}
+	if err := db.Raw("PRAGMA database_list").Scan(&databases).Error; err != nil {
+		return failedCheck(err)
+	}
+	var path string
+	for _, d := range databases {
+		if d.Name == "main" {
+			path = d.File
+		}
+	}
+	if path == "" {
+		return healthCheck{OK: true}
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return healthCheck{Error: fmt.Sprintf("db file %s is missing", filepath.Base(path))}
+	} else if err != nil {
+		return failedCheck(err)
+	}
+	free, err := freeDiskBytes(filepath.Dir(path))
+	if errors.Is(err, errFreeDiskUnknown) {
+		return healthCheck{OK: true, Value: echo.Map{"free_bytes": "unknown"}}
+	}
+	if err != nil {
+		return failedCheck(err)
+	}
+	value := echo.Map{"free_bytes": free}
+	if free < minFreeDiskBytes {
+		return healthCheck{Value: value, Error: fmt.Sprintf("less than %d MiB free", minFreeDiskBytes>>20)}
+	}
+	return healthCheck{OK: true, Value: value}
+}
+
+// function to find when the newest interaction was logged, which is null if there are none yet
+func checkLastInteraction(db *gorm.DB) healthCheck {
+	var interaction data_model.Interaction
+	err := db.Order("idInteraction DESC").Take(&interaction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return healthCheck{OK: true}
+	}
+	if err != nil {
+		return failedCheck(err)
+	}
+	return healthCheck{OK: true, Value: echo.Map{
+		"timestamp":   interaction.Timestamp,
+		"age_seconds": int64(time.Since(interaction.Timestamp).Seconds()),
+	}}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package handler
+
+// function to get the free space of a volume, which is only read on unix where the server runs, so it's
+// reported as unknown elsewhere
+func freeDiskBytes(dir string) (uint64, error) {
+	return 0, errFreeDiskUnknown
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package handler
+
+import "syscall"
+
+// function to get the space left for unprivileged writes on the volume holding dir
+func freeDiskBytes(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
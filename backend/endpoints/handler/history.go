@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/history"
+)
+
+// HISTORY HANDLER
+
+// default and largest page sizes for the history
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// HistoryHandler holds the dataset DB and the users DB used to resolve sessions to profiles
+type HistoryHandler struct {
+	DB      *gorm.DB
+	UsersDB *gorm.DB
+}
+
+// NewHistoryHandler returns a new HistoryHandler for the given DBs
+func NewHistoryHandler(db, usersDB *gorm.DB) *HistoryHandler {
+	return &HistoryHandler{DB: db, UsersDB: usersDB}
+}
+
+// GetHistory handles GET /api/:dataset/history?row=&column=&profile=&from=&to=&limit=&offset=
+func (h *HistoryHandler) GetHistory(c echo.Context) error {
+	// read the filters from the query string
+	filter, err := h.parseHistoryFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid history filter",
+			"detail": err.Error(),
+		})
+	}
+
+	// get the underlying sql connections for the shared history query
+	db, err := h.DB.DB()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to get dataset connection",
+			"detail": err.Error(),
+		})
+	}
+	usersDB, err := h.UsersDB.DB()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to get users connection",
+			"detail": err.Error(),
+		})
+	}
+
+	// run the history query
+	entries, total, err := history.Query(db, usersDB, filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to fetch history",
+			"detail": err.Error(),
+		})
+	}
+
+	// return the page along with what's needed to request the next one
+	return c.JSON(http.StatusOK, echo.Map{
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"history": entries,
+	})
+}
+
+// function to turn the query string into a history filter
+func (h *HistoryHandler) parseHistoryFilter(c echo.Context) (history.Filter, error) {
+	filter := history.Filter{Limit: defaultHistoryLimit}
+
+	// row and profile are plain ids
+	var err error
+	if filter.IDUniqueID, err = queryInt64(c, "row"); err != nil {
+		return filter, err
+	}
+	if filter.IDProfile, err = queryInt64(c, "profile"); err != nil {
+		return filter, err
+	}
+
+	// column can be the id or the name of the suggestion type
+	if column := c.QueryParam("column"); column != "" {
+		if id, err := strconv.ParseInt(column, 10, 64); err == nil {
+			filter.IDSuggestionType = id
+		} else {
+			var st data_model.SuggestionType
+			if err := h.DB.
+				Select("idSuggestionType").
+				Where("LOWER(name) = LOWER(?)", column).
+				First(&st).Error; err != nil {
+				return filter, fmt.Errorf("unknown column %q", column)
+			}
+			filter.IDSuggestionType = st.IDSuggestionType
+		}
+	}
+
+	// time range bounds are stored as UTC strings
+	if from := c.QueryParam("from"); from != "" {
+		t, _, err := parseHistoryTime(from)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = t.UTC().Format(time.DateTime)
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, dateOnly, err := parseHistoryTime(to)
+		if err != nil {
+			return filter, err
+		}
+		// a bare date includes the whole day
+		if dateOnly {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		filter.To = t.UTC().Format(time.DateTime)
+	}
+
+	// pagination
+	limit, err := queryInt64(c, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit > 0 {
+		filter.Limit = int(min(limit, maxHistoryLimit))
+	}
+	offset, err := queryInt64(c, "offset")
+	if err != nil {
+		return filter, err
+	}
+	if offset < 0 {
+		return filter, fmt.Errorf("offset must not be negative")
+	}
+	filter.Offset = int(offset)
+
+	return filter, nil
+}
+
+// function to parse an optional int64 query parameter, returning 0 if it's missing
+func queryInt64(c echo.Context, name string) (int64, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return v, nil
+}
+
+// function to parse a history time bound as RFC 3339, a UTC date time, or a bare UTC date
+func parseHistoryTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.DateTime, raw); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q", raw)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/history"
+)
+
+// editCell edits the cell of the first column of row 1 through the handler and returns the id of the edit
+func editCell(t *testing.T, h *EditHandler, value string) int64 {
+	t.Helper()
+
+	rec := serveWithSession(h.CreateEdit, http.MethodPost, "/api/edits", fmt.Sprintf(
+		`{"IDInteractionType":2,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+			`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":%q,"Active":1}`, value))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("edit to %s: status %d, body %s", value, rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Edit data_model.Edit `json:"edit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode edit: %v", err)
+	}
+	return body.Edit.IDEdit
+}
+
+func TestHistoryShowsWhatEachEditChanged(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	edits := NewEditHandler(db)
+
+	first := editCell(t, edits, "first")
+	second := editCell(t, edits, "second")
+	// reverting both raises the confidence of original above first, which mustn't change what second changed from
+	revertSecond := revertEdit(t, edits, second, http.StatusCreated)
+	revertFirst := revertEdit(t, edits, first, http.StatusCreated)
+
+	delRows := NewEditDelRowHandler(db)
+	rec := serveWithSession(delRows.CreateEditDelRow, http.MethodPost, "/api/editdelrows",
+		`{"IDUniqueID":1,"IDInteractionType":1,"IDEntryType":3,"Comment":"duplicate"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("delete row: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// the dataset db stands in for the users db, which only resolves sessions to profiles
+	rec = serveRouteWithSession(NewHistoryHandler(db, db).GetHistory, http.MethodGet, "/api/history", "/api/history?row=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("history: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Total   int             `json:"total"`
+		History []history.Entry `json:"history"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+
+	// newest first, and the edits all land in the same second so they're ordered by id
+	want := []struct {
+		action, from, to string
+		idEdit           int64
+	}{
+		{history.ActionDelRow, "original", "[row deleted]", revertFirst + 1},
+		{history.ActionRevert, "first", "original", revertFirst},
+		{history.ActionRevert, "second", "first", revertSecond},
+		{history.ActionEditCell, "first", "second", second},
+		{history.ActionEditCell, "original", "first", first},
+	}
+	if body.Total != len(want) || len(body.History) != len(want) {
+		t.Fatalf("got %d of %d history entries, want %d: %+v", len(body.History), body.Total, len(want), body.History)
+	}
+	for i, w := range want {
+		e := body.History[i]
+		if e.IDEdit != w.idEdit || e.Action != w.action || e.ChangedFrom != w.from || e.ChangedTo != w.to {
+			t.Errorf("entry %d = edit %d %s from %q to %q, want edit %d %s from %q to %q",
+				i, e.IDEdit, e.Action, e.ChangedFrom, e.ChangedTo, w.idEdit, w.action, w.from, w.to)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+)
+
+// REQUEST LOGGING
+
+// context keys of the request's logger and the writer keeping its error response
+const (
+	requestLoggerKey = "request_logger"
+	errorBodyKey     = "error_body"
+)
+
+// most of an error response body that's kept for the request log
+const maxLoggedErrorBody = 4 << 10
+
+// RequestLogger is middleware that gives every request a logger tagged with its request id, set by the
+// request id middleware that runs before it, and logs one line per request with its dataset, profile,
+// session, status and latency. error responses also log the error and detail they returned, so a
+// failing request and its db error are on the same line.
+func RequestLogger(logger *slog.Logger, datasets []string) echo.MiddlewareFunc {
+	mounted := make(map[string]bool, len(datasets))
+	for _, name := range datasets {
+		mounted[name] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			requestLogger := logger.With("request_id", c.Response().Header().Get(echo.HeaderXRequestID))
+			c.Set(requestLoggerKey, requestLogger)
+
+			// keep the body of error responses
+			w := &errorBodyWriter{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = w
+			c.Set(errorBodyKey, w)
+
+			// let echo write the response of a returned error so its status is logged
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("route", c.Path()),
+				slog.Int("status", status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			}
+			if dataset, _ := routeDataset(c.Path(), mounted); dataset != "" {
+				attrs = append(attrs, slog.String("dataset", dataset))
+			}
+			if cookieSession, err := esession.Get("session", c); err == nil {
+				if profileID, ok := getInt64(cookieSession.Values["profile_id"]); ok {
+					attrs = append(attrs, slog.Int64("profile_id", profileID))
+				}
+				if sessionID, ok := getInt64(cookieSession.Values["id_session"]); ok {
+					attrs = append(attrs, slog.Int64("session_id", sessionID))
+				}
+			}
+
+			// client errors are warnings and server errors are errors, both with what the response said
+			level := slog.LevelInfo
+			if status >= http.StatusBadRequest {
+				level = slog.LevelWarn
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+				attrs = append(attrs, errorBodyAttrs(w.body.Bytes())...)
+			}
+
+			requestLogger.LogAttrs(req.Context(), level, "request", attrs...)
+			return nil
+		}
+	}
+}
+
+// RequestLog returns the logger of the request, or the default logger outside of RequestLogger
+func RequestLog(c echo.Context) *slog.Logger {
+	if logger, ok := c.Get(requestLoggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// function to get the start of the request's error response, which is empty outside of RequestLogger
+func errorBody(c echo.Context) []byte {
+	if w, ok := c.Get(errorBodyKey).(*errorBodyWriter); ok {
+		return w.body.Bytes()
+	}
+	return nil
+}
+
+// response writer that keeps the start of the body when the status is an error
+type errorBodyWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *errorBodyWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *errorBodyWriter) Write(b []byte) (int, error) {
+	if w.status >= http.StatusBadRequest && w.body.Len() < maxLoggedErrorBody {
+		w.body.Write(b[:min(len(b), maxLoggedErrorBody-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the server's writer to flush or hijack
+func (w *errorBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// function to pull the error and detail out of an error response, or log the body as is if it isn't one
+func errorBodyAttrs(body []byte) []slog.Attr {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var parsed struct {
+		Error  interface{} `json:"error"`
+		Detail interface{} `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.Error == nil {
+		return []slog.Attr{slog.String("response", string(body))}
+	}
+
+	attrs := []slog.Attr{slog.Any("error", parsed.Error)}
+	if parsed.Detail != nil {
+		attrs = append(attrs, slog.Any("detail", parsed.Detail))
+	}
+	return attrs
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// META HANDLER
+
+// a lookup table in the meta response, keyed like its route, with the columns holding its id and name
+type metaLookup struct {
+	Key    string
+	Table  string
+	ID     string
+	Column string
+}
+
+// lookup tables whose rows the frontend maps from names to ids
+var metaLookups = []metaLookup{
+	{Key: "datatypes", Table: "DataType", ID: "idDataType", Column: "type"},
+	{Key: "databaitcreatetypes", Table: "DatabaitCreateType", ID: "idDatabaitCreateType", Column: "type"},
+	{Key: "databaitnextactions", Table: "DatabaitNextAction", ID: "idDatabaitNextAction", Column: "action"},
+	{Key: "databaittemplatetypes", Table: "DatabaitTemplateType", ID: "idDatabaitTemplateType", Column: "template"},
+	{Key: "entrytypes", Table: "EntryType", ID: "idEntryType", Column: "type"},
+	{Key: "interactiontypes", Table: "InteractionType", ID: "idInteractionType", Column: "interaction"},
+	{Key: "roles", Table: "Role", ID: "idRole", Column: "role"},
+	{Key: "searchtypes", Table: "SearchType", ID: "idSearchType", Column: "type"},
+}
+
+// row of a lookup table, whatever its columns are called
+type lookupRow struct {
+	ID   int64   `json:"id"`
+	Name *string `json:"name"`
+}
+
+// MetaHandler holds DB connection
+type MetaHandler struct {
+	DB *gorm.DB
+}
+
+// NewMetaHandler returns a new MetaHandler for the given DB
+func NewMetaHandler(db *gorm.DB) *MetaHandler {
+	return &MetaHandler{DB: db}
+}
+
+// GetMeta handles GET /api/<name>/meta, returning every lookup table as id and name pairs and the active,
+// public columns, so the frontend can look ids up by name in one call
+func (h *MetaHandler) GetMeta(c echo.Context) error {
+	db := h.DB.WithContext(c.Request().Context())
+	meta := make(echo.Map, len(metaLookups)+1)
+
+	// read the rows of every lookup table that aren't deleted
+	for _, lookup := range metaLookups {
+		rows := make([]lookupRow, 0)
+		if err := db.
+			Table(lookup.Table).
+			Select(lookup.ID + " AS id, " + lookup.Column + " AS name").
+			Where(deletedColumn + " IS NULL").
+			Order(lookup.ID).
+			Scan(&rows).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error":  "failed to fetch " + lookup.Key,
+				"detail": err.Error(),
+			})
+		}
+		meta[lookup.Key] = rows
+	}
+
+	// the active, public columns, the same as GET /columns returns them
+	columns, err := loadSuggestionTypeColumns(db)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to fetch columns",
+			"detail": err.Error(),
+		})
+	}
+	meta["suggestiontypes"] = columns
+
+	// return the lookups and columns
+	return c.JSON(http.StatusOK, meta)
+}
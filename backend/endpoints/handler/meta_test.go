@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMetaColumnsMatchColumns(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name", "City"})
+	if err := db.Exec("UPDATE SuggestionType SET valueType = 'string', width = '40%', editMode = 'dropdown' WHERE name = 'City'").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serveWithSession(NewSuggestionTypeHandler(db).GetColumns, http.MethodGet, "/api/columns", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("columns: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	var columns []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &columns); err != nil {
+		t.Fatalf("decode columns: %v", err)
+	}
+
+	rec = serveWithSession(NewMetaHandler(db).GetMeta, http.MethodGet, "/api/meta", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("meta: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	var meta struct {
+		SuggestionTypes []map[string]interface{} `json:"suggestiontypes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &meta); err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+
+	// both describe every column the same way, data type and hints included
+	if len(columns) != 2 || !reflect.DeepEqual(meta.SuggestionTypes, columns) {
+		t.Fatalf("meta columns = %v, want %v", meta.SuggestionTypes, columns)
+	}
+	if hints := columns[1]["hints"].(map[string]interface{}); hints["edit"] != "dropdown" {
+		t.Errorf("City hints = %v, want edit dropdown", hints)
+	}
+}
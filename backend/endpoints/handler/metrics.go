@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"drafty3/metrics"
+)
+
+// METRICS HANDLER
+
+// content type of the Prometheus text format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// route label of requests that matched no route, so scanners probing paths can't make a series per path
+const unmatchedRoute = "unmatched"
+
+// sqlite error messages of a db that stayed busy past the busy timeout or had a table locked
+var sqliteBusyMessages = [][]byte{
+	[]byte("database is locked"),
+	[]byte("database table is locked"),
+}
+
+// MetricsHandler counts requests as they're served and writes them with the interaction counts of every
+// dataset in the Prometheus text format
+type MetricsHandler struct {
+	// Datasets are counted on every scrape, set once they're mounted
+	Datasets []Dataset
+
+	mounted          map[string]bool
+	registry         *metrics.Registry
+	requests         *metrics.Counter
+	duration         *metrics.Histogram
+	failures         *metrics.Counter
+	busy             *metrics.Counter
+	handlerNamesOnce sync.Once
+	handlerNames     map[string]string
+}
+
+// NewMetricsHandler returns a new MetricsHandler that labels requests under /api/<name> with the dataset name
+func NewMetricsHandler(datasets []string) *MetricsHandler {
+	h := &MetricsHandler{
+		mounted:  make(map[string]bool, len(datasets)),
+		registry: metrics.NewRegistry(),
+	}
+	for _, name := range datasets {
+		h.mounted[name] = true
+	}
+
+	h.requests = h.registry.NewCounter("drafty_http_requests_total",
+		"Requests served, by method, route, dataset and status.",
+		"method", "route", "dataset", "status")
+	h.duration = h.registry.NewHistogram("drafty_http_request_duration_seconds",
+		"Time taken to serve requests, by method, route and dataset.",
+		metrics.DefaultBuckets, "method", "route", "dataset")
+	h.failures = h.registry.NewCounter("drafty_transaction_failures_total",
+		"Requests whose handler failed with a server error, rolling back any transaction it had open.",
+		"handler", "dataset")
+	h.busy = h.registry.NewCounter("drafty_sqlite_busy_errors_total",
+		"Requests that failed because a sqlite db was busy or locked.",
+		"dataset")
+	h.registry.NewGaugeFunc("drafty_interactions",
+		"Interactions logged in each dataset, by interaction type.",
+		[]string{"dataset", "interaction_type"}, h.countInteractions)
+
+	return h
+}
+
+// Middleware counts every request by route and dataset and times it. it runs inside RequestLogger so it
+// can read the error a failed request returned.
+func (h *MetricsHandler) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+
+		// let echo write the response of a returned error so its status is counted
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		dataset, route := routeDataset(c.Path(), h.mounted)
+		method := c.Request().Method
+		status := c.Response().Status
+		h.requests.Inc(method, route, dataset, strconv.Itoa(status))
+		h.duration.Observe(time.Since(start).Seconds(), method, route, dataset)
+
+		// count server errors against the handler that failed and note the ones sqlite caused
+		if status >= http.StatusInternalServerError {
+			h.failures.Inc(h.handlerName(c), dataset)
+			body := errorBody(c)
+			for _, msg := range sqliteBusyMessages {
+				if bytes.Contains(body, msg) {
+					h.busy.Inc(dataset)
+					break
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// GetMetrics handles GET /metrics
+func (h *MetricsHandler) GetMetrics(c echo.Context) error {
+	// write to a buffer first so a failed interaction count still gets a proper response
+	var buf bytes.Buffer
+	if err := h.registry.WriteText(&buf); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to write metrics",
+			"detail": err.Error(),
+		})
+	}
+	return c.Blob(http.StatusOK, metricsContentType, buf.Bytes())
+}
+
+// function to count the interactions of every dataset by type, naming types by their id if they have no name
+func (h *MetricsHandler) countInteractions() ([]metrics.Sample, error) {
+	var samples []metrics.Sample
+	for _, ds := range h.Datasets {
+		var counts []struct {
+			InteractionType string
+			Count           int64
+		}
+		if err := ds.DB.
+			Table("Interaction AS i").
+			Select("COALESCE(it.interaction, CAST(i.idInteractionType AS TEXT)) AS interaction_type, COUNT(*) AS count").
+			Joins("LEFT JOIN InteractionType AS it ON it.idInteractionType = i.idInteractionType").
+			Group("i.idInteractionType").
+			Scan(&counts).Error; err != nil {
+			return nil, err
+		}
+
+		for _, count := range counts {
+			samples = append(samples, metrics.Sample{
+				Labels: []string{ds.Name, count.InteractionType},
+				Value:  float64(count.Count),
+			})
+		}
+	}
+	return samples, nil
+}
+
+// function to name the handler of the request's route, like ClickHandler.CreateClick
+func (h *MetricsHandler) handlerName(c echo.Context) string {
+	// every route is registered before the first request so the names only need reading once
+	h.handlerNamesOnce.Do(func() {
+		h.handlerNames = make(map[string]string)
+		for _, r := range c.Echo().Routes() {
+			h.handlerNames[r.Method+" "+r.Path] = shortHandlerName(r.Name)
+		}
+	})
+
+	if name, ok := h.handlerNames[c.Request().Method+" "+c.Path()]; ok {
+		return name
+	}
+	return unmatchedRoute
+}
+
+// function to shorten a function name like drafty3/endpoints/handler.(*ClickHandler).CreateClick-fm
+func shortHandlerName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	return strings.NewReplacer("(*", "", ")", "", "-fm", "").Replace(name)
+}
+
+// function to split a route into the dataset mounted at its first segment under /api and the route
+// within the dataset, so every dataset's /clicks counts under the same route
+func routeDataset(path string, mounted map[string]bool) (string, string) {
+	if path == "" {
+		return "", unmatchedRoute
+	}
+	if segment, rest, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/"); mounted[segment] {
+		return segment, "/" + rest
+	}
+	return "", path
+}
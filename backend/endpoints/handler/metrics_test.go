@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"drafty3/go_migration/data_model"
+)
+
+// a sample line of the text format, name then optional labels then value
+var sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(?:\{(.*)\})? (\S+)$`)
+
+// one label pair, where the value can hold escaped quotes
+var labelPair = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)="((?:[^"\\]|\\.)*)"(?:,|$)`)
+
+// scrapedSample is one parsed sample line
+type scrapedSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseMetrics parses the text format the way a scraper would, failing on anything it wouldn't accept
+func parseMetrics(t *testing.T, r io.Reader) []scrapedSample {
+	t.Helper()
+
+	types := make(map[string]string)
+	var samples []scrapedSample
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				t.Fatalf("bad TYPE line %q", line)
+			}
+			if _, ok := types[fields[2]]; ok {
+				t.Fatalf("TYPE of %s given twice", fields[2])
+			}
+			types[fields[2]] = fields[3]
+		case strings.HasPrefix(line, "#"):
+			// HELP and other comments
+		default:
+			m := sampleLine.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("bad sample line %q", line)
+			}
+
+			// every sample belongs to a metric whose type came first
+			family := m[1]
+			if _, ok := types[family]; !ok {
+				for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+					if base := strings.TrimSuffix(family, suffix); types[base] == "histogram" {
+						family = base
+					}
+				}
+			}
+			if _, ok := types[family]; !ok {
+				t.Fatalf("sample %q has no TYPE line before it", line)
+			}
+
+			labels := make(map[string]string)
+			for rest := m[2]; rest != ""; {
+				pair := labelPair.FindStringSubmatch(rest)
+				if pair == nil {
+					t.Fatalf("bad labels in %q", line)
+				}
+				labels[pair[1]] = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n").Replace(pair[2])
+				rest = rest[len(pair[0]):]
+			}
+
+			value, err := strconv.ParseFloat(m[3], 64)
+			if err != nil {
+				t.Fatalf("bad value in %q: %v", line, err)
+			}
+			samples = append(samples, scrapedSample{name: m[1], labels: labels, value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	return samples
+}
+
+// findSample returns the value of the sample with the name and labels, failing if it's missing
+func findSample(t *testing.T, samples []scrapedSample, name string, labels map[string]string) float64 {
+	t.Helper()
+
+next:
+	for _, s := range samples {
+		if s.name != name || len(s.labels) != len(labels) {
+			continue
+		}
+		for k, v := range labels {
+			if s.labels[k] != v {
+				continue next
+			}
+		}
+		return s.value
+	}
+	t.Fatalf("no sample %s %v", name, labels)
+	return 0
+}
+
+func TestMetricsCanBeScraped(t *testing.T) {
+	// interaction type 1 is click, seeded by the dataset migrations
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	for _, typeID := range []int64{1, 1, 7} {
+		if err := db.Create(&data_model.Interaction{IDSession: 1, IDInteractionType: typeID}).Error; err != nil {
+			t.Fatalf("seed interaction: %v", err)
+		}
+	}
+
+	// serve through the same middleware the server uses, with a route that fails on a locked db
+	metricsHandler := NewMetricsHandler([]string{"students"})
+	metricsHandler.Datasets = []Dataset{{Name: "students", DB: db}}
+	e := echo.New()
+	e.Use(middleware.RequestID())
+	e.Use(RequestLogger(slog.New(slog.NewTextHandler(io.Discard, nil)), []string{"students"}))
+	e.Use(metricsHandler.Middleware)
+	e.GET("/metrics", metricsHandler.GetMetrics)
+	api := e.Group("/api/students")
+	api.GET("/health", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{"status": "healthy"})
+	})
+	api.POST("/edits", func(c echo.Context) error {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to create edit",
+			"detail": "database is locked",
+		})
+	})
+
+	requests := []struct{ method, target string }{
+		{http.MethodGet, "/api/students/health"},
+		{http.MethodGet, "/api/students/health"},
+		{http.MethodPost, "/api/students/edits"},
+		{http.MethodGet, "/api/students/nowhere/1"},
+	}
+	for _, r := range requests {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.target, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+	samples := parseMetrics(t, rec.Body)
+
+	// requests are counted per route within the dataset
+	if v := findSample(t, samples, "drafty_http_requests_total",
+		map[string]string{"method": "GET", "route": "/health", "dataset": "students", "status": "200"}); v != 2 {
+		t.Errorf("health requests %v, want 2", v)
+	}
+	if v := findSample(t, samples, "drafty_http_requests_total",
+		map[string]string{"method": "GET", "route": unmatchedRoute, "dataset": "", "status": "404"}); v != 1 {
+		t.Errorf("unmatched requests %v, want 1", v)
+	}
+	if v := findSample(t, samples, "drafty_http_request_duration_seconds_count",
+		map[string]string{"method": "GET", "route": "/health", "dataset": "students"}); v != 2 {
+		t.Errorf("health latency count %v, want 2", v)
+	}
+
+	// the failed edit counts against its handler and as a busy db
+	failures := 0.0
+	for _, s := range samples {
+		if s.name == "drafty_transaction_failures_total" && s.labels["dataset"] == "students" {
+			failures += s.value
+			if !strings.Contains(s.labels["handler"], "TestMetricsCanBeScraped") {
+				t.Errorf("failure counted against handler %q", s.labels["handler"])
+			}
+		}
+	}
+	if failures != 1 {
+		t.Errorf("transaction failures %v, want 1", failures)
+	}
+	if v := findSample(t, samples, "drafty_sqlite_busy_errors_total", map[string]string{"dataset": "students"}); v != 1 {
+		t.Errorf("busy errors %v, want 1", v)
+	}
+
+	// interactions are counted by type name, or by id when the type has no row
+	if v := findSample(t, samples, "drafty_interactions",
+		map[string]string{"dataset": "students", "interaction_type": "click"}); v != 2 {
+		t.Errorf("click interactions %v, want 2", v)
+	}
+	if v := findSample(t, samples, "drafty_interactions",
+		map[string]string{"dataset": "students", "interaction_type": "7"}); v != 1 {
+		t.Errorf("type 7 interactions %v, want 1", v)
+	}
+
+	// histogram buckets only grow and end at the count
+	buckets := make(map[string][]scrapedSample)
+	for _, s := range samples {
+		if s.name == "drafty_http_request_duration_seconds_bucket" {
+			key := s.labels["method"] + " " + s.labels["route"] + " " + s.labels["dataset"]
+			buckets[key] = append(buckets[key], s)
+		}
+	}
+	if len(buckets) == 0 {
+		t.Fatal("no latency buckets")
+	}
+	for key, series := range buckets {
+		bound := func(s scrapedSample) float64 {
+			v, err := strconv.ParseFloat(s.labels["le"], 64)
+			if err != nil {
+				t.Fatalf("bad le %q", s.labels["le"])
+			}
+			return v
+		}
+		sort.SliceStable(series, func(i, j int) bool { return bound(series[i]) < bound(series[j]) })
+		for i := 1; i < len(series); i++ {
+			if series[i].value < series[i-1].value {
+				t.Errorf("%s: bucket le=%s has %v, below the one before", key, series[i].labels["le"], series[i].value)
+			}
+		}
+		last := series[len(series)-1]
+		if last.labels["le"] != "+Inf" {
+			t.Errorf("%s: last bucket le=%s, want +Inf", key, last.labels["le"])
+		}
+		method, rest, _ := strings.Cut(key, " ")
+		route, dataset, _ := strings.Cut(rest, " ")
+		count := findSample(t, samples, "drafty_http_request_duration_seconds_count",
+			map[string]string{"method": method, "route": route, "dataset": dataset})
+		if last.value != count {
+			t.Errorf("%s: +Inf bucket %v, count %v", key, last.value, count)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/removal"
+)
+
+// REMOVAL HANDLER
+
+// RemovalHandler holds the users DB and every dataset DB that RemoveUserData requests are processed across
+type RemovalHandler struct {
+	DB       *gorm.DB
+	Datasets []Dataset
+}
+
+// NewRemovalHandler returns a new RemovalHandler for the given users DB and datasets
+func NewRemovalHandler(db *gorm.DB, datasets []Dataset) *RemovalHandler {
+	return &RemovalHandler{DB: db, Datasets: datasets}
+}
+
+// ProcessRemoveUserData handles POST /api/users/removeuserdata/process
+func (h *RemovalHandler) ProcessRemoveUserData(c echo.Context) error {
+	datasets := make([]removal.Dataset, 0, len(h.Datasets))
+	for _, ds := range h.Datasets {
+		datasets = append(datasets, removal.Dataset{Name: ds.Name, DB: ds.DB})
+	}
+
+	// remove the data of every profile with a pending request
+	result, err := removal.Run(h.DB, datasets, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to process remove user data requests",
+			"detail": err.Error(),
+			"result": result,
+		})
+	}
+
+	// return what was removed
+	return c.JSON(http.StatusOK, result)
+}
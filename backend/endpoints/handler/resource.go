@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// RESOURCE HANDLER
+
+// default and largest page sizes for resource lists
+const (
+	defaultResourceLimit = 100
+	maxResourceLimit     = 1000
+)
+
+// column stamped with the time a row was soft deleted, which a model needs to be deleted
+const deletedColumn = "deleted"
+
+// ResourceOperation is one of the routes a ResourceHandler can serve
+type ResourceOperation int
+
+// operations of a resource at its path
+const (
+	// ResourceGet is GET <path>/:id
+	ResourceGet ResourceOperation = iota
+	// ResourceList is GET <path>?limit=&offset=&<column>=
+	ResourceList
+	// ResourceCreate is POST <path>
+	ResourceCreate
+	// ResourceUpdate is PUT <path>/:id
+	ResourceUpdate
+	// ResourceDelete is DELETE <path>/:id, which stamps the deleted column
+	ResourceDelete
+)
+
+// ResourcePolicy holds the operations a resource serves and the roles allowed to change it
+type ResourcePolicy struct {
+	Operations []ResourceOperation
+	// Writers are the roles allowed to create, update and delete, where none leaves them open to every cookie holder
+	Writers []string
+}
+
+// ResourceHandler serves the rows of the table of T by the key column
+type ResourceHandler[T any] struct {
+	DB     *gorm.DB
+	Key    string
+	Policy ResourcePolicy
+
+	schema  *schema.Schema
+	columns map[string]bool
+}
+
+// schemas parsed for resources, shared the way gorm shares its own
+var resourceSchemas sync.Map
+
+// NewResourceHandler returns a new ResourceHandler for the table of T, where rows are looked up by key.
+// a resource that's updated or deleted needs key to be its only primary key, and one that's deleted needs
+// a deleted column, which panics at startup if they're missing.
+func NewResourceHandler[T any](db *gorm.DB, key string, policy ResourcePolicy) *ResourceHandler[T] {
+	s, err := schema.Parse(new(T), &resourceSchemas, db.NamingStrategy)
+	if err != nil {
+		panic(fmt.Sprintf("resource %T: %v", *new(T), err))
+	}
+
+	h := &ResourceHandler[T]{DB: db, Key: key, Policy: policy, schema: s, columns: make(map[string]bool)}
+	for _, name := range s.DBNames {
+		h.columns[name] = true
+	}
+	if !h.columns[key] {
+		panic(fmt.Sprintf("resource %s has no column %s", s.Name, key))
+	}
+
+	// an update or delete changes the row with the key, so the key has to pick out one row
+	for _, op := range policy.Operations {
+		if (op == ResourceUpdate || op == ResourceDelete) &&
+			(len(s.PrimaryFieldDBNames) != 1 || s.PrimaryFieldDBNames[0] != key) {
+			panic(fmt.Sprintf("resource %s can't be changed by %s, which isn't its primary key", s.Name, key))
+		}
+		if op == ResourceDelete && !h.columns[deletedColumn] {
+			panic(fmt.Sprintf("resource %s can't be deleted without a %s column", s.Name, deletedColumn))
+		}
+	}
+	return h
+}
+
+// Register adds the routes of the served operations under path, and the writers of its changes to policies
+func (h *ResourceHandler[T]) Register(g *echo.Group, path string, policies RoutePolicies) {
+	for _, op := range h.Policy.Operations {
+		var r *echo.Route
+		route := path
+		switch op {
+		case ResourceGet:
+			route = path + "/:id"
+			r = g.GET(route, h.Get)
+			r.Name = h.routeName("Get")
+		case ResourceList:
+			r = g.GET(route, h.List)
+			r.Name = h.routeName("List")
+		case ResourceCreate:
+			r = g.POST(route, h.Create)
+			r.Name = h.routeName("Create")
+		case ResourceUpdate:
+			route = path + "/:id"
+			r = g.PUT(route, h.Update)
+			r.Name = h.routeName("Update")
+		case ResourceDelete:
+			route = path + "/:id"
+			r = g.DELETE(route, h.Delete)
+			r.Name = h.routeName("Delete")
+		default:
+			continue
+		}
+
+		if r.Method != http.MethodGet && len(h.Policy.Writers) > 0 {
+			policies[r.Method+" "+route] = h.Policy.Writers
+		}
+	}
+}
+
+// Get handles GET <path>/:id, including soft deleted rows so old references to them still resolve
+func (h *ResourceHandler[T]) Get(c echo.Context) error {
+	// lookup row by the key
+	id := c.Param("id")
+
+	// try to find the row and error if can't
+	var row T
+	if err := h.DB.First(&row, h.Key+" = ?", id).Error; err != nil {
+		return h.findError(c, id, err)
+	}
+
+	// return the row
+	return c.JSON(http.StatusOK, row)
+}
+
+// List handles GET <path>?limit=&offset=&<column>=, returning a page of the rows that aren't deleted
+// and match every column given in the query string
+func (h *ResourceHandler[T]) List(c echo.Context) error {
+	// read the page and filters from the query string
+	limit, offset, filters, err := h.parseListQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid " + h.name() + " filter",
+			"detail": err.Error(),
+		})
+	}
+
+	query := h.live().Model(new(T))
+	for _, column := range filters {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: column}, Value: c.QueryParam(column)})
+	}
+
+	// count every match, then read the page in key order
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to count " + h.name(),
+			"detail": err.Error(),
+		})
+	}
+	rows := make([]T, 0)
+	if err := query.Order(clause.OrderByColumn{Column: clause.Column{Name: h.Key}}).
+		Limit(limit).
+		Offset(offset).
+		Find(&rows).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to list " + h.name(),
+			"detail": err.Error(),
+		})
+	}
+
+	// return the page along with what's needed to request the next one
+	return c.JSON(http.StatusOK, echo.Map{
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"items":  rows,
+	})
+}
+
+// Create handles POST <path>
+func (h *ResourceHandler[T]) Create(c echo.Context) error {
+	// bind request JSON to the model
+	var row T
+	if err := c.Bind(&row); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+
+	// insert into DB, where a new row is never deleted
+	if deleted := h.schema.LookUpField(deletedColumn); deleted != nil {
+		if err := deleted.Set(c.Request().Context(), reflect.ValueOf(&row).Elem(), nil); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error":  "failed to create " + h.name(),
+				"detail": err.Error(),
+			})
+		}
+	}
+	if err := h.DB.Create(&row).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to create " + h.name(),
+			"detail": err.Error(),
+		})
+	}
+
+	// return created row
+	return c.JSON(http.StatusCreated, row)
+}
+
+// Update handles PUT <path>/:id, changing the fields given in the body of a row that isn't deleted
+func (h *ResourceHandler[T]) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	// load the row so the fields missing from the body keep their values
+	var row T
+	if err := h.live().First(&row, h.Key+" = ?", id).Error; err != nil {
+		return h.findError(c, id, err)
+	}
+	ctx := c.Request().Context()
+	value := reflect.ValueOf(&row).Elem()
+	key := h.schema.LookUpField(h.Key)
+	keyValue, _ := key.ValueOf(ctx, value)
+
+	if err := c.Bind(&row); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+
+	// the key and the deleted stamp can't be changed by an update
+	if err := key.Set(ctx, value, keyValue); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to update " + h.name(),
+			"detail": err.Error(),
+		})
+	}
+	omit := []string{h.Key, clause.Associations}
+	if h.columns[deletedColumn] {
+		omit = append(omit, deletedColumn)
+	}
+	if err := h.DB.Model(&row).Select("*").Omit(omit...).Updates(&row).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to update " + h.name(),
+			"detail": err.Error(),
+		})
+	}
+
+	// return the row as it's stored
+	if err := h.DB.First(&row, h.Key+" = ?", id).Error; err != nil {
+		return h.findError(c, id, err)
+	}
+	return c.JSON(http.StatusOK, row)
+}
+
+// Delete handles DELETE <path>/:id, stamping the row as deleted so rows that reference it keep working
+func (h *ResourceHandler[T]) Delete(c echo.Context) error {
+	id := c.Param("id")
+
+	// stamp the row unless it's already deleted
+	result := h.live().Model(new(T)).Where(h.Key+" = ?", id).Update(deletedColumn, time.Now().UTC())
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to delete " + h.name(),
+			"detail": result.Error.Error(),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return h.findError(c, id, gorm.ErrRecordNotFound)
+	}
+
+	// return the deleted row
+	var row T
+	if err := h.DB.First(&row, h.Key+" = ?", id).Error; err != nil {
+		return h.findError(c, id, err)
+	}
+	return c.JSON(http.StatusOK, row)
+}
+
+// function to name the route of a method after the model, since go names the methods of every
+// ResourceHandler alike, like drafty3/endpoints/handler.(*ResourceHandler[...]).Get-fm
+func (h *ResourceHandler[T]) routeName(method string) string {
+	return fmt.Sprintf("%s.(*ResourceHandler[%s]).%s", reflect.TypeOf(h).Elem().PkgPath(), h.schema.Name, method)
+}
+
+// function to scope a query to the rows that aren't soft deleted
+func (h *ResourceHandler[T]) live() *gorm.DB {
+	if !h.columns[deletedColumn] {
+		return h.DB
+	}
+	return h.DB.Where(clause.Eq{Column: clause.Column{Name: deletedColumn}, Value: nil})
+}
+
+// function to respond to a failed lookup of one row, which is a 404 unless the db failed
+func (h *ResourceHandler[T]) findError(c echo.Context, id string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"error": h.schema.Name + " not found",
+			"id":    id,
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{
+		"error":  "failed to find " + h.name(),
+		"detail": err.Error(),
+		"id":     id,
+	})
+}
+
+// function to read the page and the columns to filter on from the query string
+func (h *ResourceHandler[T]) parseListQuery(c echo.Context) (int, int, []string, error) {
+	limit, err := queryInt64(c, "limit")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if limit <= 0 {
+		limit = defaultResourceLimit
+	}
+	offset, err := queryInt64(c, "offset")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if offset < 0 {
+		return 0, 0, nil, fmt.Errorf("offset must not be negative")
+	}
+
+	// every other parameter has to be a column, since they're put in the query by name
+	var filters []string
+	for name := range c.QueryParams() {
+		if name == "limit" || name == "offset" {
+			continue
+		}
+		if !h.columns[name] || name == deletedColumn {
+			return 0, 0, nil, fmt.Errorf("unknown column %q", name)
+		}
+		filters = append(filters, name)
+	}
+
+	return int(min(limit, maxResourceLimit)), int(offset), filters, nil
+}
+
+// function to name the model in messages, so DatabaitTweet is "databait tweet"
+func (h *ResourceHandler[T]) name() string {
+	var b strings.Builder
+	for i, r := range h.schema.Name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/history"
+)
+
+// ROW RESTORE
+
+// struct of what we expect from front end with info to make rows in Interaction and Edit and EditRestoreRow
+type restoreRowPayload struct {
+	IDInteractionType int64  `json:"IDInteractionType"`
+	Mode              string `json:"Mode"`
+	IsCorrect         int64  `json:"IsCorrect"`
+	Comment           string `json:"Comment"`
+}
+
+// RestoreRow handles POST /api/rows/:idUniqueID/restore
+func (h *EditDelRowHandler) RestoreRow(c echo.Context) error {
+	// read the cookie based session
+	sessionID, err := getCookieSessionID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error":  "failed to get active session",
+			"detail": err.Error(),
+		})
+	}
+
+	// bind request JSON filled with info for Interaction and Edit and EditRestoreRow
+	var payload restoreRowPayload
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+
+	// a restore has to say why
+	if strings.TrimSpace(payload.Comment) == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": "a comment is required to restore a row",
+		})
+	}
+
+	// row to restore
+	id := c.Param("idUniqueID")
+
+	// set up data models
+	var interaction data_model.Interaction
+	var edit data_model.Edit
+	var ers data_model.EditRestoreRow
+	var uid data_model.UniqueId
+	restoredSuggestions := make([]data_model.Suggestions, 0)
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// find the row and make sure it's deleted
+		if err := tx.First(&uid, "idUniqueID = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "row not found")
+			}
+			return err
+		}
+		if uid.Active != 0 {
+			return echo.NewHTTPError(http.StatusConflict, "row is not deleted")
+		}
+
+		// reactivate the row itself in UniqueId
+		one := int64(1)
+		if err := tx.Model(&data_model.UniqueId{}).
+			Where("idUniqueID = ?", uid.IDUniqueID).
+			Update("active", one).Error; err != nil {
+			return err
+		}
+		uid.Active = one
+
+		// find the suggestions the row's last delete deactivated, so every cell comes back as it was, including
+		// ones that were blank
+		var deleted data_model.EditDelRow
+		if err := tx.
+			Where("idUniqueID = ?", uid.IDUniqueID).
+			Order("idEdit DESC").
+			First(&deleted).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		var suggestions []data_model.Suggestions
+		if err := tx.
+			Where("idSuggestion IN (?)", tx.Model(&data_model.EditDelRowSuggestion{}).
+				Select("idSuggestion").
+				Where("idEdit = ?", deleted.IDEdit)).
+			Order("idSuggestionType ASC").
+			Find(&suggestions).Error; err != nil {
+			return err
+		}
+
+		// skip columns that have been given an active suggestion since
+		var activeTypes []int64
+		if err := tx.Model(&data_model.Suggestions{}).
+			Where("idUniqueID = ? AND active = 1", uid.IDUniqueID).
+			Pluck("idSuggestionType", &activeTypes).Error; err != nil {
+			return err
+		}
+		hasActive := make(map[int64]bool, len(activeTypes))
+		for _, idSuggestionType := range activeTypes {
+			hasActive[idSuggestionType] = true
+		}
+
+		// reactivate the rest
+		for _, s := range suggestions {
+			if hasActive[s.IDSuggestionType] {
+				continue
+			}
+
+			if err := tx.Model(&data_model.Suggestions{}).
+				Where("idSuggestion = ?", s.IDSuggestion).
+				Update("active", one).Error; err != nil {
+				return err
+			}
+			s.Active = &one
+			restoredSuggestions = append(restoredSuggestions, s)
+		}
+
+		// get the entry type restores are recorded under
+		entryTypeID, err := entryTypeID(tx, history.EntryTypeRestoreRow)
+		if err != nil {
+			return err
+		}
+
+		// create Interaction using IDSession from cookie
+		interaction = data_model.Interaction{
+			IDSession:         sessionID,
+			IDInteractionType: payload.IDInteractionType,
+		}
+		if err := tx.Create(&interaction).Error; err != nil {
+			return err
+		}
+
+		// create Edit linked to this Interaction
+		edit = data_model.Edit{
+			IDInteraction: interaction.IDInteraction,
+			IDEntryType:   entryTypeID,
+			Mode:          payload.Mode,
+			IsCorrect:     payload.IsCorrect,
+		}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+
+		// create EditRestoreRow linked to Edit and UniqueId
+		ers = data_model.EditRestoreRow{
+			IDEdit:     edit.IDEdit,
+			IDUniqueID: uid.IDUniqueID,
+			Comment:    payload.Comment,
+		}
+		if err := tx.Create(&ers).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// error handling for the transaction
+	if err != nil {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return c.JSON(httpErr.Code, echo.Map{
+				"error":      httpErr.Message,
+				"idUniqueID": id,
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to restore row",
+			"detail": err.Error(),
+		})
+	}
+
+	// return created and restored rows
+	return c.JSON(http.StatusCreated, echo.Map{
+		"interaction":    interaction,
+		"edit":           edit,
+		"uniqueId":       uid,
+		"suggestions":    restoredSuggestions,
+		"editrestorerow": ers,
+	})
+}
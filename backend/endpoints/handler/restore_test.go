@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+)
+
+// activeCells returns the active suggestion of every cell of a row by column
+func activeCells(t *testing.T, db *gorm.DB, rowID int64) map[int64]string {
+	t.Helper()
+
+	var suggestions []data_model.Suggestions
+	if err := db.Where("idUniqueID = ? AND active = 1", rowID).Find(&suggestions).Error; err != nil {
+		t.Fatal(err)
+	}
+	cells := make(map[int64]string, len(suggestions))
+	for _, s := range suggestions {
+		cells[s.IDSuggestionType] = s.Suggestion
+	}
+	return cells
+}
+
+func TestRestoreRowBringsBackDeletedCells(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name", "City", "Notes"},
+		[]string{"Ada", "London", "a"},
+		[]string{"Grace", "New York", "b"},
+	)
+
+	// a higher confidence City that isn't shown, and a Notes cell that was cleared before the delete
+	inactive, confidence := int64(0), int64(9)
+	if err := db.Create(&data_model.Suggestions{
+		IDSuggestionType: 2,
+		IDUniqueID:       1,
+		IDProfile:        1,
+		Suggestion:       "Paris",
+		Active:           &inactive,
+		Confidence:       &confidence,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&data_model.Suggestions{}).
+		Where("idSuggestionType = 3 AND idUniqueID = 1").
+		Update("active", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	before := activeCells(t, db, 1)
+
+	delRows := NewEditDelRowHandler(db)
+	rec := serveWithSession(delRows.CreateEditDelRow, http.MethodPost, "/api/editdelrows",
+		`{"IDUniqueID":1,"IDInteractionType":1,"IDEntryType":3,"Comment":"duplicate"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("delete row: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if cells := activeCells(t, db, 1); len(cells) != 0 {
+		t.Fatalf("deleted row still has active cells %v", cells)
+	}
+
+	restore := func(wantStatus int) {
+		t.Helper()
+		rec := serveRouteWithSession(delRows.RestoreRow, http.MethodPost, "/api/rows/:idUniqueID/restore",
+			"/api/rows/1/restore", `{"IDInteractionType":1,"Comment":"not a duplicate"}`)
+		if rec.Code != wantStatus {
+			t.Fatalf("restore row: status %d, body %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	// the row comes back with the cells it had, not the best suggestion of every column
+	restore(http.StatusCreated)
+	var row data_model.UniqueId
+	if err := db.First(&row, "idUniqueID = ?", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.Active != 1 {
+		t.Errorf("restored row is not active")
+	}
+	if after := activeCells(t, db, 1); !reflect.DeepEqual(after, before) {
+		t.Errorf("cells after restore = %v, want %v as before the delete", after, before)
+	}
+
+	// restoring it again is a conflict
+	restore(http.StatusConflict)
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/history"
+)
+
+// EDIT REVERT
+
+// struct of what we expect from front end with info to make the Interaction of a revert
+type revertEditPayload struct {
+	IDInteractionType int64 `json:"IDInteractionType"`
+}
+
+// RevertEdit handles POST /api/edits/:id/revert
+func (h *EditHandler) RevertEdit(c echo.Context) error {
+	// read the cookie based session
+	sessionID, err := getCookieSessionID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error":  "failed to get active session",
+			"detail": err.Error(),
+		})
+	}
+
+	// read the cookie based profile
+	profileID, err := getCookieProfileID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error":  "failed to get active profile",
+			"detail": err.Error(),
+		})
+	}
+
+	// bind request JSON filled with info for the Interaction
+	var payload revertEditPayload
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error":  "invalid request body",
+			"detail": err.Error(),
+		})
+	}
+
+	// edit to revert
+	id := c.Param("id")
+
+	// set up data models
+	var interaction data_model.Interaction
+	var edit data_model.Edit
+	var reverted data_model.Suggestions
+	var restored data_model.Suggestions
+	var editSuggestion data_model.EditSuggestion
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// find the edit and the suggestion it chose
+		var original data_model.Edit
+		if err := tx.First(&original, "idEdit = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "edit not found")
+			}
+			return err
+		}
+
+		var originalLink data_model.EditSuggestion
+		if err := tx.
+			Where("idEdit = ?", original.IDEdit).
+			Order("isChosen DESC").
+			First(&originalLink).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return echo.NewHTTPError(http.StatusBadRequest, "only cell edits can be reverted")
+			}
+			return err
+		}
+
+		if err := tx.First(&reverted, "idSuggestion = ?", originalLink.IDSuggestion).Error; err != nil {
+			return err
+		}
+
+		// the edit can only be reverted while its suggestion is still the one shown in the cell
+		if reverted.Active == nil || *reverted.Active != 1 {
+			return echo.NewHTTPError(http.StatusConflict, "cell has changed since this edit")
+		}
+
+		// get the entry type reverts are recorded under
+		revertTypeID, err := entryTypeID(tx, history.EntryTypeRevert)
+		if err != nil {
+			return err
+		}
+
+		// find the suggestion that was active before the edit. a revert links the suggestion it replaced
+		// as not chosen, and any other edit replaced the best earlier suggestion of the cell
+		priorQuery := tx.
+			Where("idSuggestionType = ? AND idUniqueID = ? AND idSuggestion < ?",
+				reverted.IDSuggestionType, reverted.IDUniqueID, reverted.IDSuggestion).
+			Order("confidence DESC").
+			Order("idSuggestion DESC")
+		if original.IDEntryType == revertTypeID {
+			priorQuery = tx.Where("idSuggestion IN (?)", tx.Model(&data_model.EditSuggestion{}).
+				Select("idSuggestion").
+				Where("idEdit = ? AND isChosen = 0", original.IDEdit))
+		}
+		var prior data_model.Suggestions
+		hasPrior := true
+		if err := priorQuery.First(&prior).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			hasPrior = false
+		}
+
+		// deactivate the reverted suggestion first, since a cell can only have one active suggestion
+		zero := int64(0)
+		if err := tx.Model(&data_model.Suggestions{}).
+			Where("idSuggestion = ?", reverted.IDSuggestion).
+			Update("active", zero).Error; err != nil {
+			return err
+		}
+		reverted.Active = &zero
+
+		// create Interaction using IDSession from cookie
+		interaction = data_model.Interaction{
+			IDSession:         sessionID,
+			IDInteractionType: payload.IDInteractionType,
+		}
+		if err := tx.Create(&interaction).Error; err != nil {
+			return err
+		}
+
+		// create Edit linked to this Interaction
+		edit = data_model.Edit{
+			IDInteraction: interaction.IDInteraction,
+			IDEntryType:   revertTypeID,
+			Mode:          original.Mode,
+			IsCorrect:     original.IsCorrect,
+		}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+
+		// the restored suggestion goes above the reverted one so it's the cell's best suggestion again
+		active := int64(1)
+		confidence := confidenceOf(reverted.Confidence) + 1
+		var isPrevSuggest int64 = 0
+		if hasPrior {
+			// reactivate the prior suggestion itself, so the cell's value stays credited to whoever wrote it
+			if err := tx.Model(&data_model.Suggestions{}).
+				Where("idSuggestion = ?", prior.IDSuggestion).
+				Updates(map[string]interface{}{"active": active, "confidence": confidence}).Error; err != nil {
+				return err
+			}
+			restored = prior
+			restored.Active = &active
+			restored.Confidence = &confidence
+			isPrevSuggest = 1
+		} else {
+			// a cell that was blank before the edit restores to blank
+			restored = data_model.Suggestions{
+				IDSuggestionType: reverted.IDSuggestionType,
+				IDUniqueID:       reverted.IDUniqueID,
+				IDProfile:        profileID,
+				Suggestion:       "",
+				Active:           &active,
+				Confidence:       &confidence,
+			}
+			if err := tx.Create(&restored).Error; err != nil {
+				return err
+			}
+		}
+
+		// create EditSuggestion linking the revert Edit and the restored Suggestion it chose
+		editSuggestion = data_model.EditSuggestion{
+			IDEdit:        edit.IDEdit,
+			IDSuggestion:  restored.IDSuggestion,
+			IsPrevSuggest: isPrevSuggest,
+			IsNew:         1 - isPrevSuggest,
+			IsChosen:      1,
+		}
+		if err := tx.Create(&editSuggestion).Error; err != nil {
+			return err
+		}
+
+		// and the reverted Suggestion it replaced, so the revert can be reverted and shown in the history
+		if err := tx.Create(&data_model.EditSuggestion{
+			IDEdit:        edit.IDEdit,
+			IDSuggestion:  reverted.IDSuggestion,
+			IsPrevSuggest: 1,
+			IsNew:         0,
+			IsChosen:      0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// error handling for the transaction
+	if err != nil {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return c.JSON(httpErr.Code, echo.Map{
+				"error": httpErr.Message,
+				"id":    id,
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to revert edit",
+			"detail": err.Error(),
+		})
+	}
+
+	// return the revert Edit and the suggestions it changed
+	return c.JSON(http.StatusCreated, echo.Map{
+		"edit":            edit,
+		"reverted":        reverted,
+		"suggestion":      restored,
+		"edit_suggestion": editSuggestion,
+	})
+}
+
+// function to get the id of the EntryType with the given type, which dataset migration 4 seeds with a fixed id
+// so every dataset records it the same
+func entryTypeID(tx *gorm.DB, entryType string) (int64, error) {
+	var et data_model.EntryType
+	if err := tx.Where("type = ?", entryType).First(&et).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("entry type %q is missing from EntryType", entryType)
+		}
+		return 0, err
+	}
+	return et.IDEntryType, nil
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/history"
+)
+
+// revertEdit reverts an edit through the handler and returns the id of the revert edit
+func revertEdit(t *testing.T, h *EditHandler, idEdit int64, wantStatus int) int64 {
+	t.Helper()
+
+	rec := serveRouteWithSession(h.RevertEdit, http.MethodPost, "/api/edits/:id/revert",
+		fmt.Sprintf("/api/edits/%d/revert", idEdit), `{"IDInteractionType":2}`)
+	if rec.Code != wantStatus {
+		t.Fatalf("revert edit %d: status %d, body %s", idEdit, rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Edit data_model.Edit `json:"edit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode revert: %v", err)
+	}
+	return body.Edit.IDEdit
+}
+
+// cellSuggestions returns every suggestion of the cell in id order
+func cellSuggestions(t *testing.T, db *gorm.DB) []data_model.Suggestions {
+	t.Helper()
+
+	var suggestions []data_model.Suggestions
+	if err := db.Where("idSuggestionType = 1 AND idUniqueID = 1").Order("idSuggestion").Find(&suggestions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return suggestions
+}
+
+func TestRevertReactivatesPriorSuggestion(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	edits := NewEditHandler(db)
+
+	rec := serveWithSession(edits.CreateEdit, http.MethodPost, "/api/edits",
+		`{"IDInteractionType":2,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+			`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":"changed","Active":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("edit: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Edit data_model.Edit `json:"edit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode edit: %v", err)
+	}
+
+	// reverting makes the original suggestion the active, best one again without copying it
+	revertID := revertEdit(t, edits, created.Edit.IDEdit, http.StatusCreated)
+	var revert data_model.Edit
+	if err := db.First(&revert, "idEdit = ?", revertID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if revert.IDEntryType != 4 {
+		t.Errorf("revert recorded under entry type %d, want the seeded 4", revert.IDEntryType)
+	}
+	suggestions := cellSuggestions(t, db)
+	if len(suggestions) != 2 {
+		t.Fatalf("got %d suggestions after revert, want 2", len(suggestions))
+	}
+	original, changed := suggestions[0], suggestions[1]
+	if *original.Active != 1 || *changed.Active != 0 {
+		t.Errorf("after revert original active=%d, changed active=%d, want 1 and 0", *original.Active, *changed.Active)
+	}
+	if *original.Confidence <= *changed.Confidence {
+		t.Errorf("restored confidence %d isn't above the reverted %d", *original.Confidence, *changed.Confidence)
+	}
+
+	// the history shows the revert as a change from the reverted value back to the original
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _, err := history.Query(sqlDB, nil, history.Filter{})
+	if err != nil {
+		t.Fatalf("query history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d history entries, want the edit and the revert", len(entries))
+	}
+	if e := entries[0]; e.IDEdit != revertID || e.Action != history.ActionRevert || e.ChangedFrom != "changed" || e.ChangedTo != "original" {
+		t.Errorf("revert history entry = %+v, want %d from changed to original", e, revertID)
+	}
+
+	// the edit can't be reverted twice, but the revert can be reverted
+	revertEdit(t, edits, created.Edit.IDEdit, http.StatusConflict)
+	revertEdit(t, edits, revertID, http.StatusCreated)
+	suggestions = cellSuggestions(t, db)
+	if len(suggestions) != 2 {
+		t.Fatalf("got %d suggestions after reverting the revert, want 2", len(suggestions))
+	}
+	if *suggestions[0].Active != 0 || *suggestions[1].Active != 1 {
+		t.Errorf("after reverting the revert original active=%d, changed active=%d, want 0 and 1",
+			*suggestions[0].Active, *suggestions[1].Active)
+	}
+}
+
+func TestRevertNeedsSeededEntryType(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	edits := NewEditHandler(db)
+	rec := serveWithSession(edits.CreateEdit, http.MethodPost, "/api/edits",
+		`{"IDInteractionType":2,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+			`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":"changed","Active":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("edit: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// without its entry type the revert fails rather than making one up
+	if err := db.Exec("DELETE FROM EntryType WHERE type = ?", history.EntryTypeRevert).Error; err != nil {
+		t.Fatal(err)
+	}
+	revertEdit(t, edits, 1, http.StatusInternalServerError)
+	var entryTypes int64
+	if err := db.Model(&data_model.EntryType{}).Count(&entryTypes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entryTypes != 4 {
+		t.Errorf("got %d entry types after the failed revert, want 4", entryTypes)
+	}
+}
+
+func TestRevertAfterInactiveSuggestion(t *testing.T) {
+	db := newDatasetDB(t, []string{"Name"}, []string{"original"})
+	edits := NewEditHandler(db)
+
+	rec := serveWithSession(edits.CreateEdit, http.MethodPost, "/api/edits",
+		`{"IDInteractionType":2,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+			`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":"changed","Active":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("edit: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// a suggestion that isn't made active ranks below the cell's shown one, so the edit can still be reverted
+	rec = serveWithSession(edits.CreateEdit, http.MethodPost, "/api/edits",
+		`{"IDInteractionType":2,"IDEntryType":1,"Mode":"normal","IsCorrect":2,`+
+			`"IDSuggestionType":1,"IDUniqueID":1,"Suggestion":"proposed","Active":0}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("inactive suggestion: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	suggestions := cellSuggestions(t, db)
+	if proposed, changed := suggestions[2], suggestions[1]; *proposed.Confidence >= *changed.Confidence {
+		t.Errorf("inactive confidence %d isn't below the active %d", *proposed.Confidence, *changed.Confidence)
+	}
+
+	revertEdit(t, edits, 1, http.StatusCreated)
+	suggestions = cellSuggestions(t, db)
+	if *suggestions[0].Active != 1 || *suggestions[1].Active != 0 || *suggestions[2].Active != 0 {
+		t.Errorf("after revert actives are %d %d %d, want only the original",
+			*suggestions[0].Active, *suggestions[1].Active, *suggestions[2].Active)
+	}
+}
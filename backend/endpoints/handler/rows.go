@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/columns"
+	"drafty3/go_migration/data_model"
+)
+
+// ROWS HANDLER
+
+// RowsHandler holds DB connection
+type RowsHandler struct {
+	DB *gorm.DB
+}
+
+// NewRowsHandler returns a new RowsHandler for the given DB
+func NewRowsHandler(db *gorm.DB) *RowsHandler {
+	return &RowsHandler{DB: db}
+}
+
+// order clause for SuggestionType columns, where columns without a columnOrder go last in id order
+const columnOrderClause = "columnOrder IS NULL, columnOrder ASC, idSuggestionType ASC"
+
+// model of the suggestion rows we resolve into grid cells
+type gridCell struct {
+	IDSuggestion     int64  `gorm:"column:idSuggestion"`
+	IDUniqueID       int64  `gorm:"column:idUniqueID"`
+	IDSuggestionType int64  `gorm:"column:idSuggestionType"`
+	Suggestion       string `gorm:"column:suggestion"`
+	Confidence       *int64 `gorm:"column:confidence"`
+}
+
+// GetRows handles GET /api/:dataset/rows
+func (h *RowsHandler) GetRows(c echo.Context) error {
+	// load the active, public columns in display order
+	var sts []data_model.SuggestionType
+	if err := h.DB.
+		Where("isActive = 1 AND isPrivate = 0").
+		Order(columnOrderClause).
+		Find(&sts).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to fetch suggestion types",
+			"detail": err.Error(),
+		})
+	}
+
+	// map each column id to its name and keep the ordered list of names
+	columnNames := make(map[int64]string, len(sts))
+	names := make([]string, 0, len(sts))
+	for _, col := range sts {
+		name := ""
+		if col.Name != nil {
+			name = *col.Name
+		}
+		columnNames[col.IDSuggestionType] = name
+		names = append(names, name)
+	}
+
+	// get every active suggestion that belongs to an active row
+	var cells []gridCell
+	if err := h.DB.
+		Table("Suggestions AS s").
+		Select("s.idSuggestion, s.idUniqueID, s.idSuggestionType, s.suggestion, s.confidence").
+		Joins("JOIN UniqueId AS u ON u.idUniqueID = s.idUniqueID").
+		Where("s.active = 1 AND u.active = 1").
+		Scan(&cells).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to fetch suggestions",
+			"detail": err.Error(),
+		})
+	}
+
+	// keep the highest confidence suggestion for each (idUniqueID, idSuggestionType) pair,
+	// breaking ties with the newest suggestion so every read picks the same one
+	type cellKey struct {
+		idUniqueID       int64
+		idSuggestionType int64
+	}
+	best := make(map[cellKey]gridCell)
+	for _, cell := range cells {
+		// skip columns that are inactive or private
+		if _, ok := columnNames[cell.IDSuggestionType]; !ok {
+			continue
+		}
+
+		key := cellKey{cell.IDUniqueID, cell.IDSuggestionType}
+		existing, found := best[key]
+		if !found || isBetterCell(cell, existing) {
+			best[key] = cell
+		}
+	}
+
+	// build one row per idUniqueID with every column present
+	rowMap := make(map[int64]map[string]interface{})
+	for _, cell := range best {
+		row, found := rowMap[cell.IDUniqueID]
+		if !found {
+			row = map[string]interface{}{"idUniqueID": cell.IDUniqueID}
+			for _, name := range names {
+				row[name] = ""
+			}
+			rowMap[cell.IDUniqueID] = row
+		}
+		row[columnNames[cell.IDSuggestionType]] = columns.NormalizeValue(cell.Suggestion)
+	}
+
+	// sort the rows by idUniqueID for consistent output order
+	ids := make([]int64, 0, len(rowMap))
+	for id := range rowMap {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, rowMap[id])
+	}
+
+	// return the column order alongside the rows since JSON objects are unordered
+	return c.JSON(http.StatusOK, echo.Map{
+		"columns": names,
+		"rows":    rows,
+	})
+}
+
+// function to treat a missing confidence as the lowest possible one
+func confidenceOf(confidence *int64) int64 {
+	if confidence == nil {
+		return 0
+	}
+	return *confidence
+}
+
+// function to check if a cell wins over another suggestion for the same cell, by confidence then newest
+func isBetterCell(cell, existing gridCell) bool {
+	if confidenceOf(cell.Confidence) != confidenceOf(existing.Confidence) {
+		return confidenceOf(cell.Confidence) > confidenceOf(existing.Confidence)
+	}
+	return cell.IDSuggestion > existing.IDSuggestion
+}
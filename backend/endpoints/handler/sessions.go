@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	esession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/user_model"
+)
+
+// SESSION EXPIRY
+
+// how often a session's end is moved forward, so every request doesn't write to the users db
+const sessionTouchInterval = time.Minute
+
+// TouchSession is middleware that slides the end of the cookie's session forward while requests keep arriving.
+// a session that already ended is followed by a new one for the same profile so later interactions aren't
+// recorded against a closed session.
+func (h *SessionsHandler) TouchSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.touchSession(c, time.Now()); err != nil {
+			RequestLog(c).Error("failed to touch session", "err", err)
+		}
+		return next(c)
+	}
+}
+
+// function to extend or replace the session in the cookie, doing nothing for requests without one
+func (h *SessionsHandler) touchSession(c echo.Context, now time.Time) error {
+	// read the cookie based session
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return err
+	}
+	sessionID, ok := getInt64(cookieSession.Values["id_session"])
+	if !ok || sessionID == 0 {
+		return nil
+	}
+
+	// find the session, leaving cookies of unknown sessions for CreateSessions to replace
+	var existing user_model.Session
+	if err := h.DB.First(&existing, "idSession = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if now.Before(existing.End) {
+		// skip the write if the session was extended recently
+		if existing.End.Sub(now) > h.Expiration-sessionTouchInterval {
+			return nil
+		}
+
+		// move the end forward from now
+		if err := h.DB.Model(&user_model.Session{}).
+			Where("idSession = ?", existing.IDSession).
+			Update("end", now.Add(h.Expiration)).Error; err != nil {
+			return err
+		}
+	} else {
+		// start a new session for the same profile since the old one is over
+		newSession, err := startSession(h.DB, existing.IDProfile, now, h.Expiration)
+		if err != nil {
+			return err
+		}
+		cookieSession.Values["id_session"] = newSession.IDSession
+	}
+
+	// save the cookie again so its max age starts over along with the session
+	return cookieSession.Save(c.Request(), c.Response())
+}
+
+// EndCurrentSession handles DELETE /api/users/sessions/current
+func (h *SessionsHandler) EndCurrentSession(c echo.Context) error {
+	// read the cookie based session from middleware
+	cookieSession, err := esession.Get("session", c)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to read cookie session",
+		})
+	}
+	sessionID, ok := getInt64(cookieSession.Values["id_session"])
+	if !ok || sessionID == 0 {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "no active session cookie",
+		})
+	}
+
+	// find the session
+	var existing user_model.Session
+	if err := h.DB.First(&existing, "idSession = ?", sessionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"error": "Session not found",
+			"id":    sessionID,
+		})
+	}
+
+	// close the session now unless it already ended
+	if err := endSession(h.DB, &existing, time.Now()); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to end session",
+			"detail": err.Error(),
+		})
+	}
+
+	// forget the session in the cookie but keep the profile so the next session continues it
+	delete(cookieSession.Values, "id_session")
+	if err := cookieSession.Save(c.Request(), c.Response()); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error": "failed to save cookie session",
+		})
+	}
+
+	// return the closed session
+	return c.JSON(http.StatusOK, echo.Map{
+		"session": existing,
+	})
+}
+
+// function to start a new session for a profile that lasts expiration unless it's touched
+func startSession(db *gorm.DB, profileID int64, now time.Time, expiration time.Duration) (user_model.Session, error) {
+	session := user_model.Session{
+		IDProfile: profileID,
+		Start:     now,
+		End:       now.Add(expiration),
+	}
+	err := db.Create(&session).Error
+	return session, err
+}
+
+// function to end a session at the given time, leaving sessions that already ended alone
+func endSession(db *gorm.DB, session *user_model.Session, now time.Time) error {
+	if !now.Before(session.End) {
+		return nil
+	}
+	if err := db.Model(&user_model.Session{}).
+		Where("idSession = ?", session.IDSession).
+		Update("end", now).Error; err != nil {
+		return err
+	}
+	session.End = now
+	return nil
+}
+
+// STORED SESSIONS
+
+// a stored cookie session as it's listed to moderators, without its values
+type storedSession struct {
+	SessionID int64     `json:"session_id"`
+	IDProfile *int64    `json:"idProfile"`
+	Expires   time.Time `json:"expires"`
+}
+
+// GetProfileCookieSessions handles GET /api/users/profiles/:id/cookiesessions
+func (h *SessionsHandler) GetProfileCookieSessions(c echo.Context) error {
+	// lookup rows by idProfile
+	id := c.Param("id")
+
+	// find the profile's sessions that haven't expired
+	var rows []user_model.Sessions
+	if err := h.DB.
+		Where("idProfile = ? AND expires > ?", id, time.Now().Unix()).
+		Order("session_id").
+		Find(&rows).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to fetch sessions",
+			"detail": err.Error(),
+		})
+	}
+
+	// return the rows without their values
+	stored := make([]storedSession, 0, len(rows))
+	for _, row := range rows {
+		stored = append(stored, storedSession{
+			SessionID: row.SessionID,
+			IDProfile: row.IDProfile,
+			Expires:   time.Unix(row.Expires, 0).UTC(),
+		})
+	}
+	return c.JSON(http.StatusOK, stored)
+}
+
+// DeleteProfileCookieSessions handles DELETE /api/users/profiles/:id/cookiesessions
+func (h *SessionsHandler) DeleteProfileCookieSessions(c echo.Context) error {
+	// revoke rows by idProfile
+	id := c.Param("id")
+
+	// deleting the rows logs the profile out everywhere since its cookies no longer point to anything
+	result := h.DB.Delete(&user_model.Sessions{}, "idProfile = ?", id)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to revoke sessions",
+			"detail": result.Error.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"revoked": result.RowsAffected,
+	})
+}
+
+// DeleteCookieSession handles DELETE /api/users/cookiesessions/:id
+func (h *SessionsHandler) DeleteCookieSession(c echo.Context) error {
+	// revoke row by session_id
+	id := c.Param("id")
+
+	result := h.DB.Delete(&user_model.Sessions{}, "session_id = ?", id)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"error":  "failed to revoke session",
+			"detail": result.Error.Error(),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"error": "Session not found",
+			"id":    id,
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"drafty3/go_migration/data_model"
+)
+
+// EDIT VALIDATION
+
+// cellValue is one value a client wants to write into a column
+type cellValue struct {
+	IDSuggestionType int64
+	Value            string
+}
+
+// cellError describes why a cell value was rejected
+type cellError struct {
+	IDSuggestionType int64  `json:"idSuggestionType"`
+	Column           string `json:"column,omitempty"`
+	Value            string `json:"value"`
+	Reason           string `json:"reason"`
+}
+
+// date formats accepted for date columns
+var dateLayouts = []string{
+	time.DateOnly,
+	time.RFC3339,
+	time.DateTime,
+	"2006/01/02",
+	"01/02/2006",
+	"1/2/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2006-01",
+	"2006",
+}
+
+// amounts accepted for currency columns, like $1,234.50 or -12
+var currencyPattern = regexp.MustCompile(`^-?[$€£¥]?\s?(\d{1,3}(,\d{3})+|\d+)(\.\d{1,2})?$`)
+
+// function to check cell values against the rules of their SuggestionType and return every rejected cell
+func validateCells(db *gorm.DB, cells []cellValue) ([]cellError, error) {
+	// load the suggestion types of every cell at once
+	ids := make([]int64, 0, len(cells))
+	for _, cell := range cells {
+		ids = append(ids, cell.IDSuggestionType)
+	}
+	var sts []data_model.SuggestionType
+	if err := db.Where("idSuggestionType IN ?", ids).Find(&sts).Error; err != nil {
+		return nil, err
+	}
+	types := make(map[int64]data_model.SuggestionType, len(sts))
+	for _, st := range sts {
+		types[st.IDSuggestionType] = st
+	}
+
+	// load the active vocabulary of those columns for the closed vocabulary check
+	vocabulary, err := loadVocabulary(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// check each cell and collect the rejected ones
+	var cellErrors []cellError
+	for _, cell := range cells {
+		st, ok := types[cell.IDSuggestionType]
+		if !ok {
+			cellErrors = append(cellErrors, cellError{
+				IDSuggestionType: cell.IDSuggestionType,
+				Value:            cell.Value,
+				Reason:           "unknown column",
+			})
+			continue
+		}
+
+		// a closed column without any active values has nothing to check against, so it's left unrestricted
+		reason := validateValue(st, cell.Value)
+		if reason == "" && st.IsFreeEdit == 0 && len(vocabulary[cell.IDSuggestionType]) > 0 {
+			reason = checkVocabulary(vocabulary[cell.IDSuggestionType], cell.Value)
+		}
+		if reason != "" {
+			column := ""
+			if st.Name != nil {
+				column = *st.Name
+			}
+			cellErrors = append(cellErrors, cellError{
+				IDSuggestionType: cell.IDSuggestionType,
+				Column:           column,
+				Value:            cell.Value,
+				Reason:           reason,
+			})
+		}
+	}
+
+	return cellErrors, nil
+}
+
+// function to check one value against its SuggestionType, returning why it's rejected or "" if it's fine
+func validateValue(st data_model.SuggestionType, value string) string {
+	// the column has to be writable
+	if st.IsActive == 0 {
+		return "column is not active"
+	}
+	if st.IsEditable == 0 {
+		return "column is not editable"
+	}
+
+	// multi select values are checked item by item
+	items := splitCellValue(value)
+	if len(items) == 0 {
+		if st.CanBeBlank == 0 {
+			return "value can not be blank"
+		}
+		return ""
+	}
+
+	// compile the column regex so it has to match each whole item
+	var re *regexp.Regexp
+	if st.Regex != "" && st.Regex != ".*" {
+		var err error
+		re, err = regexp.Compile(`^(?:` + st.Regex + `)$`)
+		if err != nil {
+			slog.Warn("suggestion type has an invalid regex",
+				"idSuggestionType", st.IDSuggestionType, "regex", st.Regex, "err", err)
+			re = nil
+		}
+	}
+
+	for _, item := range items {
+		if item == "" {
+			return "list items can not be blank"
+		}
+		if re != nil && !re.MatchString(item) {
+			return "value does not match the column format"
+		}
+		if st.IsDate == 1 && !isDate(item) {
+			return "value is not a valid date"
+		}
+		if st.IsLink == 1 && !isLink(item) {
+			return "value is not a valid link"
+		}
+		if st.IsCurrency == 1 && !isCurrency(item) {
+			return "value is not a valid currency amount"
+		}
+	}
+
+	return ""
+}
+
+// function to load the active SuggestionTypeValues of the given columns as a set per column
+func loadVocabulary(db *gorm.DB, ids []int64) (map[int64]map[string]bool, error) {
+	var stvs []data_model.SuggestionTypeValues
+	if err := db.
+		Where("idSuggestionType IN ? AND active = 1", ids).
+		Find(&stvs).Error; err != nil {
+		return nil, err
+	}
+
+	vocabulary := make(map[int64]map[string]bool)
+	for _, stv := range stvs {
+		if vocabulary[stv.IDSuggestionType] == nil {
+			vocabulary[stv.IDSuggestionType] = make(map[string]bool)
+		}
+		vocabulary[stv.IDSuggestionType][strings.TrimSpace(stv.Value)] = true
+	}
+
+	return vocabulary, nil
+}
+
+// function to check every item of a closed vocabulary cell is one of the allowed values, returning why it's rejected or "" if it's fine
+func checkVocabulary(allowed map[string]bool, value string) string {
+	for _, item := range splitCellValue(value) {
+		if !allowed[item] {
+			return "value is not one of the allowed values"
+		}
+	}
+	return ""
+}
+
+// function to add new values of free edit columns to their vocabulary so their dropdowns learn them.
+// only columns that already have a vocabulary learn, so plain free text columns don't grow one.
+func learnVocabulary(tx *gorm.DB, cells []cellValue) error {
+	// find the free edit columns among the cells
+	ids := make([]int64, 0, len(cells))
+	for _, cell := range cells {
+		ids = append(ids, cell.IDSuggestionType)
+	}
+	var freeEditIDs []int64
+	if err := tx.Model(&data_model.SuggestionType{}).
+		Where("idSuggestionType IN ? AND isFreeEdit = 1", ids).
+		Pluck("idSuggestionType", &freeEditIDs).Error; err != nil {
+		return err
+	}
+	if len(freeEditIDs) == 0 {
+		return nil
+	}
+
+	// keep the ones that have a vocabulary, counting inactive values too
+	var vocabularyIDs []int64
+	if err := tx.Model(&data_model.SuggestionTypeValues{}).
+		Distinct("idSuggestionType").
+		Where("idSuggestionType IN ?", freeEditIDs).
+		Pluck("idSuggestionType", &vocabularyIDs).Error; err != nil {
+		return err
+	}
+	hasVocabulary := make(map[int64]bool, len(vocabularyIDs))
+	for _, id := range vocabularyIDs {
+		hasVocabulary[id] = true
+	}
+
+	// insert every item, leaving existing values alone so inactive ones stay inactive
+	for _, cell := range cells {
+		if !hasVocabulary[cell.IDSuggestionType] {
+			continue
+		}
+		for _, item := range splitCellValue(cell.Value) {
+			if item == "" {
+				continue
+			}
+			stv := data_model.SuggestionTypeValues{
+				IDSuggestionType: cell.IDSuggestionType,
+				Value:            item,
+				Active:           1,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&stv).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// function to split a cell value into its items, where string[] cells are JSON arrays and anything else is one item
+func splitCellValue(value string) []string {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		var arr []string
+		if err := json.Unmarshal([]byte(s), &arr); err == nil {
+			for i := range arr {
+				arr[i] = strings.TrimSpace(arr[i])
+			}
+			return arr
+		}
+	}
+
+	return []string{s}
+}
+
+// function to check if a value parses with one of the accepted date formats
+func isDate(value string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// function to check if a value is an absolute http or https url
+func isLink(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// function to check if a value is a currency amount with at most two decimals
+func isCurrency(value string) bool {
+	if !currencyPattern.MatchString(value) {
+		return false
+	}
+	amount := strings.NewReplacer("$", "", "€", "", "£", "", "¥", "", ",", "", " ", "").Replace(value)
+	_, err := strconv.ParseFloat(amount, 64)
+	return err == nil
+}
+
+// function to send the rejected cells back as a 422
+func invalidCellsResponse(c echo.Context, cellErrors []cellError) error {
+	return c.JSON(http.StatusUnprocessableEntity, echo.Map{
+		"error": "invalid cell values",
+		"cells": cellErrors,
+	})
+}
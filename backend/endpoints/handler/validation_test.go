@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"testing"
+
+	"drafty3/go_migration/data_model"
+)
+
+func TestClosedVocabulary(t *testing.T) {
+	db := newDatasetDB(t, []string{"University", "Country"}, []string{"MIT", "USA"})
+
+	// both columns are closed, but only University has allowed values
+	if err := db.Model(&data_model.SuggestionType{}).
+		Where("idSuggestionType IN ?", []int64{1, 2}).
+		Update("isFreeEdit", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&data_model.SuggestionTypeValues{IDSuggestionType: 1, Value: "MIT", Active: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		cell       cellValue
+		wantReject bool
+	}{
+		{"allowed value", cellValue{1, "MIT"}, false},
+		{"value outside the vocabulary", cellValue{1, "Harvard"}, true},
+		{"column without a vocabulary", cellValue{2, "Canada"}, false},
+	}
+	for _, tc := range cases {
+		cellErrors, err := validateCells(db, []cellValue{tc.cell})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if rejected := len(cellErrors) > 0; rejected != tc.wantReject {
+			t.Errorf("%s: rejected = %v, want %v (%+v)", tc.name, rejected, tc.wantReject, cellErrors)
+		}
+	}
+}
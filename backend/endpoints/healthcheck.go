@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"drafty3/config"
+)
+
+// how long the health check waits for the server to start answering
+const healthCheckWait = 30 * time.Second
+
+// function to wait for the server started with the same config to answer GET /api/health, run by
+// `drafty-backend healthcheck` after systemd starts it. only a failed connection is retried, since any
+// answer means the server is up, and anything but a 200 fails the check.
+func runHealthCheck() error {
+	cfg, err := config.LoadServer()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("addr %q: %w", cfg.Addr, err)
+	}
+	// a server listening on every interface is reached on loopback
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+	url := "http://" + net.JoinHostPort(host, port) + "/api/health"
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	deadline := time.Now().Add(healthCheckWait)
+	for {
+		resp, err := client.Get(url)
+		if err != nil {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("no answer from %s after %s: %w", url, healthCheckWait, err)
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		slog.Info("health check", "url", url, "status", resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			return errors.New(strings.TrimSpace(string(body)))
+		}
+		return nil
+	}
+}
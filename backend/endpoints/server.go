@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/sessions"
 	esession "github.com/labstack/echo-contrib/session"
@@ -15,7 +21,12 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"drafty3/config"
 	"drafty3/endpoints/handler"
+	"drafty3/go_migration/data_model"
+	"drafty3/go_migration/user_model"
+	"drafty3/migrations"
+	"drafty3/sessionstore"
 )
 
 // Source - https://stackoverflow.com/a/10510783
@@ -33,258 +44,300 @@ func pathExists(path string) (bool, error) {
 	return false, err
 }
 
-func resolveDdPath(key string) string {
-	var devDbPath string = "../db"
-	var prodDbPath string = "/vol/drafty3/backend/db"
-
-	dbRoot := os.Getenv(key)
-	if dbRoot == "" {
-		prodDbPathExists, _ := pathExists(prodDbPath)
-		if prodDbPathExists {
-			return prodDbPath
-		}
-		return devDbPath
+// operations served by the generic resources
+var (
+	// lookup tables are listed by the frontend and kept up by moderators
+	lookupOperations = []handler.ResourceOperation{
+		handler.ResourceGet,
+		handler.ResourceList,
+		handler.ResourceCreate,
+		handler.ResourceUpdate,
+		handler.ResourceDelete,
 	}
-	return dbRoot
-}
+	// logs of what people did are written once and read back one at a time
+	logOperations = []handler.ResourceOperation{handler.ResourceGet, handler.ResourceCreate}
+)
 
-// students test db and routes currently disabled
+// policies of the generic resources
+var (
+	moderators   = []string{handler.RoleAdmin, handler.RoleModerator}
+	lookupPolicy = handler.ResourcePolicy{Operations: lookupOperations, Writers: moderators}
+	// logs are written by every cookie holder
+	logPolicy = handler.ResourcePolicy{Operations: logOperations}
+	// raw writes skip the edit flow so only moderators make them
+	rawWritePolicy = handler.ResourcePolicy{Operations: logOperations, Writers: moderators}
+	// rows written by their own flow are only read back through the resource
+	readOnlyPolicy = handler.ResourcePolicy{Operations: []handler.ResourceOperation{handler.ResourceGet}}
+)
 
-// create all api routes for main db and handlers for those routes
-func registerRoutes(api *echo.Group, db *gorm.DB) {
+// create all api routes for a dataset db and handlers for those routes
+func registerRoutes(api *echo.Group, name string, db *gorm.DB, usersDB *gorm.DB) {
 	// create handlers with dataset db
-	suggestionsHandler := handler.NewSuggestionsHandler(db)
-	aliasHandler := handler.NewAliasHandler(db)
+	healthHandler := handler.NewHealthHandler(usersDB, []handler.Dataset{{Name: name, DB: db}})
+	rowsHandler := handler.NewRowsHandler(db)
+	historyHandler := handler.NewHistoryHandler(db, usersDB)
+	metaHandler := handler.NewMetaHandler(db)
 	clickHandler := handler.NewClickHandler(db)
-	dataTypeHandler := handler.NewDataTypeHandler(db)
-	databaitCreateTypeHandler := handler.NewDatabaitCreateTypeHandler(db)
-	databaitNextActionHandler := handler.NewDatabaitNextActionHandler(db)
-	databaitTemplateTypeHandler := handler.NewDatabaitTemplateTypeHandler(db)
-	doubleClickHandler := handler.NewDoubleClickHandler(db)
-	editSuggestionHandler := handler.NewEditSuggestionHandler(db)
-	entryTypeHandler := handler.NewEntryTypeHandler(db)
-	interactionHandler := handler.NewInteractionHandler(db)
-	databaitTweetHandler := handler.NewDatabaitTweetHandler(db)
 	editHandler := handler.NewEditHandler(db)
-	interactionTypeHandler := handler.NewInteractionTypeHandler(db)
 	removeUserDataHandler := handler.NewRemoveUserDataHandler(db)
-	roleHandler := handler.NewRoleHandler(db)
-	searchTypeHandler := handler.NewSearchTypeHandler(db)
-	selectRangeHandler := handler.NewSelectRangeHandler(db)
 	suggestionTypeHandler := handler.NewSuggestionTypeHandler(db)
-	copyColumnHandler := handler.NewCopyColumnHandler(db)
 	searchHandler := handler.NewSearchHandler(db)
-	searchMultiHandler := handler.NewSearchMultiHandler(db)
-	sortHandler := handler.NewSortHandler(db)
 	suggestionTypeValuesHandler := handler.NewSuggestionTypeValuesHandler(db)
-	uniqueIdHandler := handler.NewUniqueIdHandler(db)
-	commentsHandler := handler.NewCommentsHandler(db)
-	commentVoteHandler := handler.NewCommentVoteHandler(db)
-	commentsViewHandler := handler.NewCommentsViewHandler(db)
-	databaitsHandler := handler.NewDatabaitsHandler(db)
-	databaitVisitHandler := handler.NewDatabaitVisitHandler(db)
 	editDelRowHandler := handler.NewEditDelRowHandler(db)
-	helpUsHandler := handler.NewHelpUsHandler(db)
-	copyHandler := handler.NewCopyHandler(db)
 	editNewRowHandler := handler.NewEditNewRowHandler(db)
-	pasteHandler := handler.NewPasteHandler(db)
-	searchGoogleHandler := handler.NewSearchGoogleHandler(db)
-	viewChangeHandler := handler.NewViewChangeHandler(db)
-	visitHandler := handler.NewVisitHandler(db)
 
-	log.Println("ENTERED registerRoutes")
+	slog.Debug("registering dataset routes")
 
 	// Health
-	api.GET("/health", handler.HealthCheck)
-
-	// Suggestions
-	api.GET("/suggestions/:id", suggestionsHandler.GetSuggestion)
-	api.POST("/suggestions", suggestionsHandler.CreateSuggestion)
+	api.GET("/health", healthHandler.GetHealth)
+
+	// Rows
+	api.GET("/rows", rowsHandler.GetRows)
+
+	// History
+	api.GET("/history", historyHandler.GetHistory)
+
+	// Meta, every lookup table and column in one call
+	api.GET("/meta", metaHandler.GetMeta)
+
+	// lookup tables
+	handler.NewResourceHandler[data_model.DataType](db, "idDataType", lookupPolicy).
+		Register(api, "/datatypes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.DatabaitCreateType](db, "idDatabaitCreateType", lookupPolicy).
+		Register(api, "/databaitcreatetypes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.DatabaitNextAction](db, "idDatabaitNextAction", lookupPolicy).
+		Register(api, "/databaitnextactions", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.DatabaitTemplateType](db, "idDatabaitTemplateType", lookupPolicy).
+		Register(api, "/databaittemplatetypes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.EntryType](db, "idEntryType", lookupPolicy).
+		Register(api, "/entrytypes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.InteractionType](db, "idInteractionType", lookupPolicy).
+		Register(api, "/interactiontypes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Role](db, "idRole",
+		handler.ResourcePolicy{Operations: lookupOperations, Writers: []string{handler.RoleAdmin}}).
+		Register(api, "/roles", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.SearchType](db, "idSearchType", lookupPolicy).
+		Register(api, "/searchtypes", datasetRoutePolicies)
+
+	// Alias, kept up by moderators but without a deleted column
+	handler.NewResourceHandler[data_model.Alias](db, "idAlias", handler.ResourcePolicy{
+		Operations: []handler.ResourceOperation{
+			handler.ResourceGet, handler.ResourceList, handler.ResourceCreate, handler.ResourceUpdate,
+		},
+		Writers: moderators,
+	}).Register(api, "/alias", datasetRoutePolicies)
 
-	// Alias
-	api.GET("/alias/:id", aliasHandler.GetAlias)
-	api.POST("/alias", aliasHandler.CreateAlias)
+	// SuggestionType, looked up by name or served as the grid's columns, where a column is hidden by updating
+	// isActive and shown differently by updating valueType, width and editMode
+	api.GET("/suggestiontypes/:name", suggestionTypeHandler.GetSuggestionType)
+	api.GET("/columns", suggestionTypeHandler.GetColumns)
+	handler.NewResourceHandler[data_model.SuggestionType](db, "idSuggestionType", handler.ResourcePolicy{
+		Operations: []handler.ResourceOperation{handler.ResourceList, handler.ResourceCreate, handler.ResourceUpdate},
+		Writers:    moderators,
+	}).Register(api, "/suggestiontypes", datasetRoutePolicies)
 
-	// Click
-	api.GET("/clicks/:id", clickHandler.GetClick)
+	// SuggestionTypeValues, looked up by suggestion type
+	api.GET("/suggestiontypevalues/:id", suggestionTypeValuesHandler.GetSuggestionTypeValues)
+	handler.NewResourceHandler[data_model.SuggestionTypeValues](db, "idSuggestionType", handler.ResourcePolicy{
+		Operations: []handler.ResourceOperation{handler.ResourceList, handler.ResourceCreate},
+		Writers:    moderators,
+	}).Register(api, "/suggestiontypevalues", datasetRoutePolicies)
+
+	// raw writes that skip the edit flow
+	handler.NewResourceHandler[data_model.Suggestions](db, "idSuggestion", rawWritePolicy).
+		Register(api, "/suggestions", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.EditSuggestion](db, "idEdit", rawWritePolicy).
+		Register(api, "/editsuggestion", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.UniqueId](db, "idUniqueID", rawWritePolicy).
+		Register(api, "/uniqueids", datasetRoutePolicies)
+
+	// interactions written with their own flow
+	handler.NewResourceHandler[data_model.Click](db, "idInteraction", readOnlyPolicy).
+		Register(api, "/clicks", datasetRoutePolicies)
 	api.POST("/clicks", clickHandler.CreateClick)
 
-	// DataType
-	api.GET("/datatypes/:id", dataTypeHandler.GetDataType)
-	api.POST("/datatypes", dataTypeHandler.CreateDataType)
-
-	// DatabaitCreateType
-	api.GET("/databaitcreatetypes/:id", databaitCreateTypeHandler.GetDatabaitCreateType)
-	api.POST("/databaitcreatetypes", databaitCreateTypeHandler.CreateDatabaitCreateType)
-
-	// DatabaitNextAction
-	api.GET("/databaitnextactions/:id", databaitNextActionHandler.GetDatabaitNextAction)
-	api.POST("/databaitnextactions", databaitNextActionHandler.CreateDatabaitNextAction)
-
-	// DatabaitTemplateType
-	api.GET("/databaittemplatetypes/:id", databaitTemplateTypeHandler.GetDatabaitTemplateType)
-	api.POST("/databaittemplatetypes", databaitTemplateTypeHandler.CreateDatabaitTemplateType)
-
-	// DoubleClick
-	api.GET("/doubleclicks/:id", doubleClickHandler.GetDoubleClick)
-	api.POST("/doubleclicks", doubleClickHandler.CreateDoubleClick)
-
-	// EditSuggestion
-	api.GET("/editsuggestion/:id", editSuggestionHandler.GetEditSuggestion)
-	api.POST("/editsuggestion", editSuggestionHandler.CreateEditSuggestion)
-
-	// EntryType
-	api.GET("/entrytypes/:id", entryTypeHandler.GetEntryType)
-	api.POST("/entrytypes", entryTypeHandler.CreateEntryType)
-
-	// Interaction
-	api.GET("/interactions/:id", interactionHandler.GetInteraction)
-	api.POST("/interactions", interactionHandler.CreateInteraction)
-
-	// DatabaitTweet
-	api.GET("/databaittweets/:id", databaitTweetHandler.GetDatabaitTweet)
-	api.POST("/databaittweets", databaitTweetHandler.CreateDatabaitTweet)
-
-	// Edit
-	api.GET("/edits/:id", editHandler.GetEdit)
+	handler.NewResourceHandler[data_model.Edit](db, "idEdit", readOnlyPolicy).
+		Register(api, "/edits", datasetRoutePolicies)
 	api.POST("/edits", editHandler.CreateEdit)
+	api.POST("/edits/:id/revert", editHandler.RevertEdit)
 
-	// InteractionType
-	api.GET("/interactiontypes/:id", interactionTypeHandler.GetInteractionType)
-	api.POST("/interactiontypes", interactionTypeHandler.CreateInteractionType)
-
-	// RemoveUserData
-	api.GET("/removeuserdata/:id", removeUserDataHandler.GetRemoveUserData)
+	handler.NewResourceHandler[data_model.RemoveUserData](db, "id_removeuserdata", readOnlyPolicy).
+		Register(api, "/removeuserdata", datasetRoutePolicies)
 	api.POST("/removeuserdata", removeUserDataHandler.CreateRemoveUserData)
 
-	// Role
-	api.GET("/roles/:id", roleHandler.GetRole)
-	api.POST("/roles", roleHandler.CreateRole)
-
-	// SearchType
-	api.GET("/searchtypes/:id", searchTypeHandler.GetSearchType)
-	api.POST("/searchtypes", searchTypeHandler.CreateSearchType)
-
-	// SelectRange
-	api.GET("/selectranges/:id", selectRangeHandler.GetSelectRange)
-	api.POST("/selectranges", selectRangeHandler.CreateSelectRange)
-
-	// SuggestionType
-	api.GET("/suggestiontypes/:name", suggestionTypeHandler.GetSuggestionType)
-	api.POST("/suggestiontypes", suggestionTypeHandler.CreateSuggestionType)
-
-	// CopyColumn
-	api.GET("/copycolumns/:id", copyColumnHandler.GetCopyColumn)
-	api.POST("/copycolumns", copyColumnHandler.CreateCopyColumn)
-
-	// Search
-	api.GET("/searches/:id", searchHandler.GetSearch)
+	handler.NewResourceHandler[data_model.Search](db, "idInteraction", readOnlyPolicy).
+		Register(api, "/searches", datasetRoutePolicies)
 	api.POST("/searches", searchHandler.CreateSearch)
 
-	// SearchMulti
-	api.GET("/searchmultis/:id", searchMultiHandler.GetSearchMulti)
-	api.POST("/searchmultis", searchMultiHandler.CreateSearchMulti)
-
-	// Sort
-	api.GET("/sorts/:id", sortHandler.GetSort)
-	api.POST("/sorts", sortHandler.CreateSort)
-
-	// SuggestionTypeValues
-	api.GET("/suggestiontypevalues/:id", suggestionTypeValuesHandler.GetSuggestionTypeValues)
-	api.POST("/suggestiontypevalues", suggestionTypeValuesHandler.CreateSuggestionTypeValues)
-
-	// UniqueId
-	api.GET("/uniqueids/:id", uniqueIdHandler.GetUniqueId)
-	api.POST("/uniqueids", uniqueIdHandler.CreateUniqueId)
-
-	// Comments
-	api.GET("/comments/:id", commentsHandler.GetComments)
-	api.POST("/comments", commentsHandler.CreateComments)
-
-	// CommentVote
-	api.GET("/commentvotes/:id", commentVoteHandler.GetCommentVote)
-	api.POST("/commentvotes", commentVoteHandler.CreateCommentVote)
-
-	// CommentsView
-	api.GET("/commentsviews/:id", commentsViewHandler.GetCommentsView)
-	api.POST("/commentsviews", commentsViewHandler.CreateCommentsView)
-
-	// Databaits
-	api.GET("/databaits/:id", databaitsHandler.GetDatabaits)
-	api.POST("/databaits", databaitsHandler.CreateDatabaits)
-
-	// DatabaitVisit
-	api.GET("/databaitvisits/:id", databaitVisitHandler.GetDatabaitVisit)
-	api.POST("/databaitvisits", databaitVisitHandler.CreateDatabaitVisit)
-
-	// EditDelRow
-	api.GET("/editdelrows/:id", editDelRowHandler.GetEditDelRow)
+	handler.NewResourceHandler[data_model.EditDelRow](db, "idEdit", readOnlyPolicy).
+		Register(api, "/editdelrows", datasetRoutePolicies)
 	api.POST("/editdelrows", editDelRowHandler.CreateEditDelRow)
+	api.POST("/rows/:idUniqueID/restore", editDelRowHandler.RestoreRow)
 
-	// HelpUs
-	api.GET("/helpus/:id", helpUsHandler.GetHelpUs)
-	api.POST("/helpus", helpUsHandler.CreateHelpUs)
+	handler.NewResourceHandler[data_model.EditNewRow](db, "idEdit", readOnlyPolicy).
+		Register(api, "/editnewrows", datasetRoutePolicies)
+	api.POST("/editnewrows", editNewRowHandler.CreateEditNewRow)
 
-	// Copy
-	api.GET("/copies/:id", copyHandler.GetCopy)
-	api.POST("/copies", copyHandler.CreateCopy)
+	// interactions written as they are
+	handler.NewResourceHandler[data_model.DoubleClick](db, "idInteraction", logPolicy).Register(api, "/doubleclicks", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Interaction](db, "idInteraction", logPolicy).Register(api, "/interactions", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.DatabaitTweet](db, "idDatabaitTweet", logPolicy).Register(api, "/databaittweets", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.SelectRange](db, "idInteraction", logPolicy).Register(api, "/selectranges", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.CopyColumn](db, "idInteraction", logPolicy).Register(api, "/copycolumns", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.SearchMulti](db, "idInteraction", logPolicy).Register(api, "/searchmultis", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Sort](db, "idInteraction", logPolicy).Register(api, "/sorts", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Comments](db, "idComment", logPolicy).Register(api, "/comments", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.CommentVote](db, "idCommentVote", logPolicy).Register(api, "/commentvotes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.CommentsView](db, "idCommentsView", logPolicy).Register(api, "/commentsviews", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Databaits](db, "idDatabait", logPolicy).Register(api, "/databaits", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.DatabaitVisit](db, "idInteraction", logPolicy).Register(api, "/databaitvisits", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.HelpUs](db, "idHelpUs", logPolicy).Register(api, "/helpus", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Copy](db, "idInteraction", logPolicy).Register(api, "/copies", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Paste](db, "idInteraction", logPolicy).Register(api, "/pastes", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.SearchGoogle](db, "IdInteraction", logPolicy).Register(api, "/searchgoogles", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.ViewChange](db, "idInteraction", logPolicy).Register(api, "/viewchanges", datasetRoutePolicies)
+	handler.NewResourceHandler[data_model.Visit](db, "idVisit", logPolicy).Register(api, "/visits", datasetRoutePolicies)
+}
 
-	// EditNewRow
-	api.GET("/editnewrows/:id", editNewRowHandler.GetEditNewRow)
-	api.POST("/editnewrows", editNewRowHandler.CreateEditNewRow)
+// roles allowed on dataset routes that write tables directly or moderate edits, which the generic
+// resources add the writers of their lookup tables and raw writes to as they're registered.
+// routes not listed here are open to every cookie holder.
+var datasetRoutePolicies = handler.RoutePolicies{
+	// moderation
+	"POST /edits/:id/revert":         {handler.RoleAdmin, handler.RoleModerator},
+	"POST /rows/:idUniqueID/restore": {handler.RoleAdmin, handler.RoleModerator},
+}
 
-	// Paste
-	api.GET("/pastes/:id", pasteHandler.GetPaste)
-	api.POST("/pastes", pasteHandler.CreatePaste)
+// roles allowed on users routes that look at other people's profiles, look at or revoke their sessions or
+// remove their data
+var userRoutePolicies = handler.RoutePolicies{
+	"GET /profiles/:id":                   {handler.RoleAdmin, handler.RoleModerator},
+	"GET /profiles/:id/cookiesessions":    {handler.RoleAdmin, handler.RoleModerator},
+	"DELETE /profiles/:id/cookiesessions": {handler.RoleAdmin, handler.RoleModerator},
+	"DELETE /cookiesessions/:id":          {handler.RoleAdmin, handler.RoleModerator},
+	"POST /removeuserdata/process":        {handler.RoleAdmin},
+}
 
-	// SearchGoogle
-	api.GET("/searchgoogles/:id", searchGoogleHandler.GetSearchGoogle)
-	api.POST("/searchgoogles", searchGoogleHandler.CreateSearchGoogle)
+// roles allowed on admin routes, which every route under /api/admin needs a policy for
+var adminRoutePolicies = handler.RoutePolicies{
+	"GET /config": {handler.RoleAdmin},
+}
 
-	// ViewChange
-	api.GET("/viewchanges/:id", viewChangeHandler.GetViewChange)
-	api.POST("/viewchanges", viewChangeHandler.CreateViewChange)
+// function to make sure every policy names a route registered under every prefix it's used for, so a typo can't leave a route open
+func checkRoutePolicies(routes []*echo.Route, policies handler.RoutePolicies, prefixes ...string) error {
+	registered := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		registered[r.Method+" "+r.Path] = true
+	}
 
-	// Visit
-	api.GET("/visits/:id", visitHandler.GetVisit)
-	api.POST("/visits", visitHandler.CreateVisit)
+	for route := range policies {
+		method, path, _ := strings.Cut(route, " ")
+		for _, prefix := range prefixes {
+			if !registered[method+" "+prefix+path] {
+				return fmt.Errorf("policy for %q matches no route under %s", route, prefix)
+			}
+		}
+	}
+	return nil
 }
 
 // create all api routes for users db and handlers for those routes
-func registerUserRoutes(api *echo.Group, usersDB *gorm.DB) {
+func registerUserRoutes(api *echo.Group, usersDB *gorm.DB, datasets []handler.Dataset, sessionExpiry time.Duration) {
 	profileHandler := handler.NewProfileHandler(usersDB)
-	sessionsHandler := handler.NewSessionsHandler(usersDB)
+	sessionsHandler := handler.NewSessionsHandler(usersDB, sessionExpiry)
+	accountsHandler := handler.NewAccountsHandler(usersDB, sessionExpiry)
+	removalHandler := handler.NewRemovalHandler(usersDB, datasets)
 
-	api.GET("/profiles/:id", profileHandler.GetProfile)
+	handler.NewResourceHandler[user_model.Profile](usersDB, "idProfile", readOnlyPolicy).
+		Register(api, "/profiles", userRoutePolicies)
 	api.POST("/profiles", profileHandler.CreateProfile)
 
-	api.GET("/sessions/:id", sessionsHandler.GetSessions)
+	handler.NewResourceHandler[user_model.Session](usersDB, "idSession", readOnlyPolicy).
+		Register(api, "/sessions", userRoutePolicies)
 	api.POST("/sessions", sessionsHandler.CreateSessions)
+	api.DELETE("/sessions/current", sessionsHandler.EndCurrentSession)
+
+	api.GET("/profiles/:id/cookiesessions", sessionsHandler.GetProfileCookieSessions)
+	api.DELETE("/profiles/:id/cookiesessions", sessionsHandler.DeleteProfileCookieSessions)
+	api.DELETE("/cookiesessions/:id", sessionsHandler.DeleteCookieSession)
+
+	api.POST("/signup", accountsHandler.Signup)
+	api.POST("/login", accountsHandler.Login)
+	api.POST("/logout", accountsHandler.Logout)
+
+	api.POST("/removeuserdata/process", removalHandler.ProcessRemoveUserData)
 }
 
 // main function to set up the echo server and connect to dbs
 func main() {
-	// create echo instance
+	// log json lines to stdout, which the log package writes through as well
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	// `drafty-backend healthcheck` waits for a running server to answer instead of starting one
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := runHealthCheck(); err != nil {
+			fatal("health check failed", "err", err)
+		}
+		return
+	}
+
+	// create echo instance, leaving startup messages to the json log
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
 
-	// set up session middleware with cookie store and a temporary secret key
-	store := sessions.NewCookieStore([]byte("temp_secret_key2"))
+	// stop on the signals systemd and ctrl-c send, which the background jobs watch too
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// load the config file, environment overrides and session config, stopping on anything invalid
+	cfg, err := config.LoadServer()
+	if err != nil {
+		fatal("failed to load config", "err", err)
+	}
+	slog.Info("loaded config", "environment", cfg.Environment, "db_root", cfg.DBRoot)
 
+	// connect to users db using gorm
+	dbUsers, err := gorm.Open(sqlite.Open(config.SQLiteDSN(cfg.UsersDB)), &gorm.Config{})
+	if err != nil {
+		fatal("failed to connect users db", "err", err)
+	}
+	migrateDB("users", dbUsers, migrations.Users, cfg.Migrate)
+
+	// set up session middleware with a store that keeps sessions in the users db, where the cookie holds
+	// the session id signed with the newest key and read with any of them
+	store := sessionstore.New(dbUsers, cfg.Session.KeyPairs()...)
 	store.Options = &sessions.Options{
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
+		Secure:   cfg.Session.Secure,
+		SameSite: cfg.Session.SameSite,
+	}
+	store.MaxAge(int(cfg.Session.MaxAge.Seconds()))
+	go store.Cleanup(ctx, cfg.SessionCleanupInterval)
+
+	// load the dataset registry from the db root
+	datasets, err := config.LoadDatasets(cfg.DBRoot)
+	if err != nil {
+		fatal("failed to load datasets", "err", err)
+	}
+	datasetNames := make([]string, 0, len(datasets))
+	for _, ds := range datasets {
+		datasetNames = append(datasetNames, ds.Name)
 	}
 
+	// tag every request with an id, sent back in X-Request-ID, and log it once it's done
+	e.Use(middleware.RequestID())
 	e.Use(esession.Middleware(store))
+	e.Use(handler.RequestLogger(slog.Default(), datasetNames))
+
+	// count and time every request for GET /metrics
+	metricsHandler := handler.NewMetricsHandler(datasetNames)
+	e.Use(metricsHandler.Middleware)
 
-	// add testing environment and live site CORS policy
+	// allow the configured frontends, by default the testing environment and the live site
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{
-			"http://localhost:4321",
-			"https://uri-hax.github.io",
-		},
+		AllowOrigins: cfg.CORSOrigins,
 		AllowMethods: []string{
 			http.MethodGet,
 			http.MethodPost,
@@ -297,44 +350,168 @@ func main() {
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 		},
+		ExposeHeaders:    []string{echo.HeaderXRequestID},
 		AllowCredentials: true,
 	}))
 
-	// get db paths from environment variables or use defaults
-	// dbRoot := resolveDdPath("DB_ROOT")
-	csprofsPath := filepath.Join(resolveDdPath("DB_PATH_CSPROFS"), "drafty_new_gorm.db")
-	usersPath := filepath.Join(resolveDdPath("DB_PATH_USERS"), "users_gorm.db")
+	// create api group
+	api := e.Group("/api")
 
-	// connect to db using gorm
-	dbCsprofs, err := gorm.Open(sqlite.Open(csprofsPath), &gorm.Config{})
-	if err != nil {
-		log.Fatal("failed to connect csprofs db:", err)
+	// keep the cookie's session open while requests to the datasets keep arriving
+	sessionsHandler := handler.NewSessionsHandler(dbUsers, cfg.SessionExpiry)
+
+	// check the caller's role on the routes that have a policy
+	authorizer := handler.NewAuthorizer(dbUsers, datasetRoutePolicies)
+	userAuthorizer := handler.NewAuthorizer(dbUsers, userRoutePolicies)
+	adminAuthorizer := handler.NewAuthorizer(dbUsers, adminRoutePolicies)
+
+	// connect to each dataset db and register its routes
+	mounted := make([]handler.Dataset, 0, len(datasets))
+	for _, ds := range datasets {
+		// make sure the file exists so sqlite doesn't create an empty db
+		exists, err := pathExists(ds.Path)
+		if err != nil {
+			fatal("failed to stat dataset db", "dataset", ds.Name, "err", err)
+		}
+		if !exists {
+			fatal("dataset db not found", "dataset", ds.Name, "path", ds.Path)
+		}
+
+		db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(ds.Path)), &gorm.Config{})
+		if err != nil {
+			fatal("failed to connect dataset db", "dataset", ds.Name, "err", err)
+		}
+		migrateDB(ds.Name, db, migrations.Dataset, cfg.Migrate)
+
+		group := api.Group("/"+ds.Name, sessionsHandler.TouchSession, authorizer.Authorize("/api/"+ds.Name))
+		registerRoutes(group, ds.Name, db, dbUsers)
+		mounted = append(mounted, handler.Dataset{Name: ds.Name, DB: db})
+		slog.Info("mounted dataset", "dataset", ds.Name, "path", ds.Path)
 	}
 
-	// dbStudents, err := gorm.Open(sqlite.Open(studentsPath), &gorm.Config{})
-	// if err != nil {
-	// 	log.Fatal("failed to connect students db:", err)
-	// }
+	// check every db the server has open
+	healthHandler := handler.NewHealthHandler(dbUsers, mounted)
+	api.GET("/health", healthHandler.GetHealth)
 
-	// connect to users db using gorm
-	dbUsers, err := gorm.Open(sqlite.Open(usersPath), &gorm.Config{})
+	// list the mounted datasets and their columns
+	datasetsHandler := handler.NewDatasetsHandler(mounted)
+	api.GET("/datasets", datasetsHandler.GetDatasets)
+
+	// serve request metrics and the interaction counts of the mounted datasets to a scraper
+	metricsHandler.Datasets = mounted
+	e.GET("/metrics", metricsHandler.GetMetrics)
+
+	// register routes for users
+	registerUserRoutes(api.Group("/users", userAuthorizer.Authorize("/api/users")), dbUsers, mounted, cfg.SessionExpiry)
+
+	// show admins the config the server is running with
+	configHandler := handler.NewConfigHandler(cfg)
+	admin := api.Group("/admin", adminAuthorizer.Authorize("/api/admin"))
+	admin.GET("/config", configHandler.GetConfig)
+
+	// make sure every route policy is in effect
+	prefixes := make([]string, 0, len(mounted))
+	for _, ds := range mounted {
+		prefixes = append(prefixes, "/api/"+ds.Name)
+	}
+	if err := checkRoutePolicies(e.Routes(), datasetRoutePolicies, prefixes...); err != nil {
+		fatal("invalid route policies", "err", err)
+	}
+	if err := checkRoutePolicies(e.Routes(), userRoutePolicies, "/api/users"); err != nil {
+		fatal("invalid route policies", "err", err)
+	}
+	if err := checkRoutePolicies(e.Routes(), adminRoutePolicies, "/api/admin"); err != nil {
+		fatal("invalid route policies", "err", err)
+	}
+
+	// log what's being served before listening so a deploy's log shows it
+	logRoutes(e.Routes())
+	slog.Info("mounted datasets", "datasets", datasetNames)
+
+	// start the server and log failures
+	go func() {
+		slog.Info("server running", "addr", cfg.Addr)
+		if err := e.Start(cfg.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("server stopped", "err", err)
+		}
+	}()
+
+	// wait for a signal, then let in-flight requests finish before closing the dbs under them
+	<-ctx.Done()
+	stop()
+	slog.Info("shutting down, waiting for in-flight requests", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to drain requests", "err", err)
+	}
+
+	for _, ds := range mounted {
+		closeDB(ds.Name, ds.DB)
+	}
+	closeDB("users", dbUsers)
+	slog.Info("server stopped")
+}
+
+// function to log an error and exit, for failures the server can't start with
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// function to bring a db to the schema of this binary, applying its pending migrations if apply is set and
+// stopping if it still needs some or was migrated by a newer binary
+func migrateDB(name string, db *gorm.DB, set migrations.Set, apply bool) {
+	if !apply {
+		current, pending, err := migrations.Status(db, set)
+		if err != nil {
+			fatal("failed to check db migrations", "db", name, "err", err)
+		}
+		if len(pending) > 0 {
+			fatal("db has pending migrations, run them with go run ./migrate", "db", name, "version", current, "pending", len(pending))
+		}
+		return
+	}
+
+	done, err := migrations.Up(db, set, 0)
+	for _, m := range done {
+		slog.Info("applied migration", "db", name, "version", m.Version, "migration", m.Name)
+	}
 	if err != nil {
-		log.Fatal("failed to connect users db:", err)
+		fatal("failed to migrate db", "db", name, "err", err)
 	}
+}
 
-	// create api group
-	api := e.Group("/api")
+// function to log every registered route, sorted by path then method
+func logRoutes(routes []*echo.Route) {
+	sorted := make([]*echo.Route, len(routes))
+	copy(sorted, routes)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
 
-	// register routes for each dataset and users
-	registerRoutes(api.Group("/csprofs"), dbCsprofs)
-	//registerRoutes(api.Group("/students"), dbStudents)
-	registerUserRoutes(api.Group("/users"), dbUsers)
+	slog.Info("registered routes", "count", len(sorted))
+	for _, r := range sorted {
+		slog.Info("route", "method", r.Method, "path", r.Path)
+	}
+}
 
-	// start the server and log failures
-	log.Println("Server running on http://localhost:8081")
-	e.Logger.Fatal(e.Start(":8081"))
+// function to checkpoint a db's write-ahead log into the db file and close it, so the next start
+// doesn't have to replay it. a db that isn't in WAL mode skips the checkpoint.
+func closeDB(name string, db *gorm.DB) {
+	if err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+		slog.Error("failed to checkpoint db", "db", name, "err", err)
+	}
 
-	for _, r := range e.Routes() {
-		log.Println(r.Method, r.Path)
+	sqlDB, err := db.DB()
+	if err != nil {
+		slog.Error("failed to get db handle", "db", name, "err", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("failed to close db", "db", name, "err", err)
 	}
 }
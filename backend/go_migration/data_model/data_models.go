@@ -20,22 +20,25 @@ type Click struct {
 func (Click) TableName() string { return "Click" }
 
 type DataType struct {
-	IDDataType int64   `gorm:"column:idDataType;primaryKey;autoIncrement"`
-	Type       *string `gorm:"column:type"`
+	IDDataType int64      `gorm:"column:idDataType;primaryKey;autoIncrement"`
+	Type       *string    `gorm:"column:type"`
+	Deleted    *time.Time `gorm:"column:deleted"`
 }
 func (DataType) TableName() string { return "DataType" }
 
 type DatabaitCreateType struct {
-	IDDatabaitCreateType int64   `gorm:"column:idDatabaitCreateType;primaryKey;autoIncrement"`
-	Type                 *string `gorm:"column:type"`
+	IDDatabaitCreateType int64      `gorm:"column:idDatabaitCreateType;primaryKey;autoIncrement"`
+	Type                 *string    `gorm:"column:type"`
+	Deleted              *time.Time `gorm:"column:deleted"`
 
 	Databaits []Databaits `gorm:"foreignKey:IDDatabaitCreateType;references:IDDatabaitCreateType"`
 }
 func (DatabaitCreateType) TableName() string { return "DatabaitCreateType" }
 
 type DatabaitNextAction struct {
-	IDDatabaitNextAction int64   `gorm:"column:idDatabaitNextAction;primaryKey;autoIncrement"`
-	Action               *string `gorm:"column:action"`
+	IDDatabaitNextAction int64      `gorm:"column:idDatabaitNextAction;primaryKey;autoIncrement"`
+	Action               *string    `gorm:"column:action"`
+	Deleted              *time.Time `gorm:"column:deleted"`
 
 	DatabaitTweets []DatabaitTweet `gorm:"foreignKey:NextAction;references:IDDatabaitNextAction"`
 	Databaits      []Databaits     `gorm:"foreignKey:NextAction;references:IDDatabaitNextAction"`
@@ -43,8 +46,9 @@ type DatabaitNextAction struct {
 func (DatabaitNextAction) TableName() string { return "DatabaitNextAction" }
 
 type DatabaitTemplateType struct {
-	IDDatabaitTemplateType int64   `gorm:"column:idDatabaitTemplateType;primaryKey;autoIncrement"`
-	Template               *string `gorm:"column:template"`
+	IDDatabaitTemplateType int64      `gorm:"column:idDatabaitTemplateType;primaryKey;autoIncrement"`
+	Template               *string    `gorm:"column:template"`
+	Deleted                *time.Time `gorm:"column:deleted"`
 
 	Databaits []Databaits `gorm:"foreignKey:IDDatabaitTemplateType;references:IDDatabaitTemplateType"`
 }
@@ -67,8 +71,9 @@ type EditSuggestion struct {
 func (EditSuggestion) TableName() string { return "Edit_Suggestion" }
 
 type EntryType struct {
-	IDEntryType int64   `gorm:"column:idEntryType;primaryKey;autoIncrement"`
-	Type        *string `gorm:"column:type"`
+	IDEntryType int64      `gorm:"column:idEntryType;primaryKey;autoIncrement"`
+	Type        *string    `gorm:"column:type"`
+	Deleted     *time.Time `gorm:"column:deleted"`
 
 	Edits []Edit `gorm:"foreignKey:IDEntryType;references:IDEntryType"`
 }
@@ -126,8 +131,9 @@ type Edit struct {
 func (Edit) TableName() string { return "Edit" }
 
 type InteractionType struct {
-	IDInteractionType int64   `gorm:"column:idInteractionType;primaryKey;autoIncrement"`
-	Interaction       *string `gorm:"column:interaction"`
+	IDInteractionType int64      `gorm:"column:idInteractionType;primaryKey;autoIncrement"`
+	Interaction       *string    `gorm:"column:interaction"`
+	Deleted           *time.Time `gorm:"column:deleted"`
 
 	Interactions []Interaction `gorm:"foreignKey:IDInteractionType;references:IDInteractionType"`
 }
@@ -148,27 +154,30 @@ type Profile struct {
 func (Profile) TableName() string { return "Profile" }
 
 type RemoveUserData struct {
-	IDRemoveUserData int64     `gorm:"column:id_removeuserdata;primaryKey;autoIncrement"`
-	IDProfile        int64     `gorm:"column:id_profile;not null"`
-	IDSession        int64     `gorm:"column:id_session;not null"`
-	Timestamp        time.Time `gorm:"column:timestamp;default:CURRENT_TIMESTAMP"`
+	IDRemoveUserData int64      `gorm:"column:id_removeuserdata;primaryKey;autoIncrement"`
+	IDProfile        int64      `gorm:"column:id_profile;not null"`
+	IDSession        int64      `gorm:"column:id_session;not null"`
+	Timestamp        time.Time  `gorm:"column:timestamp;default:CURRENT_TIMESTAMP"`
+	Completed        *time.Time `gorm:"column:completed;index:index_completed_removeuserdata"`
 }
 func (RemoveUserData) TableName() string { return "RemoveUserData" }
 
 type Role struct {
-	IDRole int64  `gorm:"column:idRole;primaryKey;autoIncrement"`
-	Role   string `gorm:"column:role;not null"`
+	IDRole  int64      `gorm:"column:idRole;primaryKey;autoIncrement"`
+	Role    string     `gorm:"column:role;not null"`
+	Deleted *time.Time `gorm:"column:deleted"`
 
 	Profiles []Profile `gorm:"foreignKey:IDRole;references:IDRole"`
 }
 func (Role) TableName() string { return "Role" }
 
 type SearchType struct {
-	IDSearchType int64  `gorm:"column:idSearchType;primaryKey;autoIncrement"`
-	Type         string `gorm:"column:type;not null"`
+	IDSearchType int64      `gorm:"column:idSearchType;primaryKey;autoIncrement"`
+	Type         string     `gorm:"column:type;not null"`
+	Deleted      *time.Time `gorm:"column:deleted"`
 
-	Searches     []Search     `gorm:"foreignKey:IDSearchType;references:IDSearchType"`
-	SearchMultis []SearchMulti`gorm:"foreignKey:IDSearchType;references:IDSearchType"`
+	Searches     []Search      `gorm:"foreignKey:IDSearchType;references:IDSearchType"`
+	SearchMultis []SearchMulti `gorm:"foreignKey:IDSearchType;references:IDSearchType"`
 }
 func (SearchType) TableName() string { return "SearchType" }
 
@@ -202,12 +211,15 @@ type SuggestionType struct {
 	IsEditable       int64   `gorm:"column:isEditable;not null;default:1"`
 	IsPrivate        int64   `gorm:"column:isPrivate;not null;default:0"`
 	ColumnOrder      *int64  `gorm:"column:columnOrder"`
-
-	Suggestions        []Suggestions        `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
-	Searches           []Search             `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
-	SearchMultis       []SearchMulti        `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
-	Sorts              []Sort               `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
-	CopyColumns        []CopyColumn         `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
+	ValueType        *string `gorm:"column:valueType"`
+	Width            *string `gorm:"column:width"`
+	EditMode         *string `gorm:"column:editMode"`
+
+	Suggestions        []Suggestions          `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
+	Searches           []Search               `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
+	SearchMultis       []SearchMulti          `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
+	Sorts              []Sort                 `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
+	CopyColumns        []CopyColumn           `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
 	SuggestionTypeVals []SuggestionTypeValues `gorm:"foreignKey:IDSuggestionType;references:IDSuggestionType"`
 }
 func (SuggestionType) TableName() string { return "SuggestionType" }
@@ -259,13 +271,14 @@ type UniqueId struct {
 	Active     int64   `gorm:"column:active;not null;default:1"`
 	Notes      *string `gorm:"column:notes"`
 
-	CommentsList      []Comments      `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	CommentsViews     []CommentsView  `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	Databaits         []Databaits     `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	EditDelRows       []EditDelRow    `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	HelpUsEntries     []HelpUs        `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	SuggestionsList   []Suggestions   `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
-	SearchGoogles     []SearchGoogle  `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	CommentsList      []Comments       `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	CommentsViews     []CommentsView   `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	Databaits         []Databaits      `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	EditDelRows       []EditDelRow     `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	EditRestoreRows   []EditRestoreRow `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	HelpUsEntries     []HelpUs         `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	SuggestionsList   []Suggestions    `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
+	SearchGoogles     []SearchGoogle   `gorm:"foreignKey:IDUniqueID;references:IDUniqueID"`
 }
 func (UniqueId) TableName() string { return "UniqueId" }
 
@@ -329,6 +342,19 @@ type EditDelRow struct {
 }
 func (EditDelRow) TableName() string { return "Edit_DelRow" }
 
+type EditDelRowSuggestion struct {
+	IDEdit       int64 `gorm:"column:idEdit;not null;index:index_edit_delrow_suggestion_idEdit"`
+	IDSuggestion int64 `gorm:"column:idSuggestion;not null"`
+}
+func (EditDelRowSuggestion) TableName() string { return "Edit_DelRow_Suggestion" }
+
+type EditRestoreRow struct {
+	IDUniqueID int64  `gorm:"column:idUniqueID;not null"`
+	IDEdit     int64  `gorm:"column:idEdit;not null"`
+	Comment    string `gorm:"column:comment;not null"`
+}
+func (EditRestoreRow) TableName() string { return "Edit_RestoreRow" }
+
 type HelpUs struct {
 	IDHelpUs      int64     `gorm:"column:idHelpUs;primaryKey;autoIncrement"`
 	IDInteraction int64     `gorm:"column:idInteraction;not null"`
@@ -345,8 +371,8 @@ func (HelpUs) TableName() string { return "HelpUs" }
 
 type Suggestions struct {
 	IDSuggestion     int64     `gorm:"column:idSuggestion;primaryKey;autoIncrement;index:idSuggestion_2;uniqueIndex:idSuggestion"`
-	IDSuggestionType int64     `gorm:"column:idSuggestionType;not null;index:fk_Suggestion_SuggestionType1_idx"`
-	IDUniqueID       int64     `gorm:"column:idUniqueID;not null;index:fk_Suggestion_UniqueID_idx"`
+	IDSuggestionType int64     `gorm:"column:idSuggestionType;not null;index:fk_Suggestion_SuggestionType1_idx;uniqueIndex:ux_Suggestions_active_cell,priority:1,where:active = 1"`
+	IDUniqueID       int64     `gorm:"column:idUniqueID;not null;index:fk_Suggestion_UniqueID_idx;uniqueIndex:ux_Suggestions_active_cell,priority:2"`
 	IDProfile        int64     `gorm:"column:idProfile;not null;default:2"`
 	Suggestion       string    `gorm:"column:suggestion;not null;default:''"`
 	Active           *int64    `gorm:"column:active;not null;default:1"`
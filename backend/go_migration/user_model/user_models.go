@@ -14,9 +14,29 @@ func (Session) TableName() string { return "Session" }
 
 type Profile struct {
 	IDProfile   int64     `gorm:"column:idProfile;primaryKey;autoIncrement"`
+	IDRole      int64     `gorm:"column:idRole;not null;default:2;index:index_idRole_profileTable"`
+	Username    *string   `gorm:"column:username;uniqueIndex:unique_username_profile"`
+	Email       *string   `gorm:"column:email;uniqueIndex:unique_email_profile" json:"-"`
+	Password    *string   `gorm:"column:password" json:"-"`
 	DateCreated time.Time `gorm:"column:date_created;default:CURRENT_TIMESTAMP"`
 	DateUpdated time.Time `gorm:"column:date_updated;default:CURRENT_TIMESTAMP"`
 
 	Sessions []Session `gorm:"foreignKey:IDProfile;references:IDProfile"`
 }
-func (Profile) TableName() string { return "Profile" }
\ No newline at end of file
+func (Profile) TableName() string { return "Profile" }
+
+type Role struct {
+	IDRole int64  `gorm:"column:idRole;primaryKey;autoIncrement"`
+	Role   string `gorm:"column:role;not null"`
+
+	Profiles []Profile `gorm:"foreignKey:IDRole;references:IDRole"`
+}
+func (Role) TableName() string { return "Role" }
+
+type Sessions struct {
+	SessionID int64   `gorm:"column:session_id;primaryKey;autoIncrement"`
+	IDProfile *int64  `gorm:"column:idProfile;index:index_idProfile_sessions"`
+	Expires   int64   `gorm:"column:expires;not null;index:index_expires_sessions"`
+	Data      *string `gorm:"column:data"`
+}
+func (Sessions) TableName() string { return "sessions" }
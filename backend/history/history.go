@@ -0,0 +1,543 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"drafty3/columns"
+)
+
+// actions shown in the history
+const (
+	ActionEditCell   = "edit cell"
+	ActionNewRow     = "new row"
+	ActionDelRow     = "del row"
+	ActionRevert     = "revert"
+	ActionRestoreRow = "restore row"
+)
+
+// EntryType.type of the edits the backend records itself
+const (
+	EntryTypeRevert     = "revert"
+	EntryTypeRestoreRow = "restore row"
+)
+
+// value shown as the new value of a deleted row
+const deletedRowValue = "[row deleted]"
+
+// Header is the header row of the edit history csv
+var Header = []string{
+	"When",
+	"EditedBy",
+	"Action",
+	"WhoWasEdited",
+	"Column",
+	"ChangedFrom",
+	"ChangedTo",
+}
+
+// Entry is one change in the edit history
+type Entry struct {
+	IDEdit           int64  `json:"idEdit"`
+	IDUniqueID       int64  `json:"idUniqueID"`
+	IDSuggestionType *int64 `json:"idSuggestionType"`
+	IDProfile        *int64 `json:"idProfile"`
+	When             string `json:"When"`
+	EditedBy         string `json:"EditedBy"`
+	Action           string `json:"Action"`
+	WhoWasEdited     string `json:"WhoWasEdited"`
+	Column           string `json:"Column"`
+	ChangedFrom      string `json:"ChangedFrom"`
+	ChangedTo        string `json:"ChangedTo"`
+}
+
+// Record returns the entry as a csv row matching Header
+func (e Entry) Record() []string {
+	return []string{
+		e.When,
+		e.EditedBy,
+		e.Action,
+		e.WhoWasEdited,
+		e.Column,
+		e.ChangedFrom,
+		e.ChangedTo,
+	}
+}
+
+// Filter narrows down the history, where zero values mean no filter
+type Filter struct {
+	IDUniqueID       int64
+	IDSuggestionType int64
+	IDProfile        int64
+	// From and To are UTC times formatted as 2006-01-02 15:04:05
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+// every edit flattened into one row per changed cell or row. a revert also links the suggestion it
+// replaced, not chosen, which is what it changed from rather than a change of its own. any other cell edit
+// changed from the suggestion the newest earlier edit of the cell chose, or for the cell's first edit the best
+// suggestion it had from before any edit, since reverts raise confidences after the fact.
+const historyQuery = `
+	SELECT
+		e.idEdit AS idEdit,
+		i.idSession AS idSession,
+		datetime(i.timestamp) AS timestamp,
+		CASE WHEN et.type = '` + EntryTypeRevert + `' THEN '` + ActionRevert + `' ELSE '` + ActionEditCell + `' END AS action,
+		s.idUniqueID AS idUniqueID,
+		s.idSuggestionType AS idSuggestionType,
+		s.idProfile AS idProfile,
+		COALESCE(
+			CASE WHEN et.type = '` + EntryTypeRevert + `' THEN (
+				SELECT r.suggestion
+				FROM Edit_Suggestion rs
+				JOIN Suggestions r ON r.idSuggestion = rs.idSuggestion
+				WHERE rs.idEdit = e.idEdit AND rs.isChosen = 0
+				LIMIT 1
+			) END,
+			(
+				SELECT p.suggestion
+				FROM Suggestions p
+				JOIN Edit_Suggestion ps ON ps.idSuggestion = p.idSuggestion
+				WHERE p.idUniqueID = s.idUniqueID
+				  AND p.idSuggestionType = s.idSuggestionType
+				  AND ps.isChosen = 1
+				  AND ps.idEdit < e.idEdit
+				ORDER BY ps.idEdit DESC
+				LIMIT 1
+			),
+			(
+				SELECT p.suggestion
+				FROM Suggestions p
+				WHERE p.idUniqueID = s.idUniqueID
+				  AND p.idSuggestionType = s.idSuggestionType
+				  AND p.idSuggestion < s.idSuggestion
+				  AND NOT EXISTS (SELECT 1 FROM Edit_Suggestion x WHERE x.idSuggestion = p.idSuggestion AND x.idEdit < e.idEdit)
+				ORDER BY COALESCE(p.confidence, 0) DESC, p.idSuggestion DESC
+				LIMIT 1
+			),
+			''
+		) AS changedFrom,
+		s.suggestion AS changedTo
+	FROM Edit e
+	JOIN Interaction i ON i.idInteraction = e.IdInteraction
+	JOIN Edit_Suggestion es ON es.idEdit = e.idEdit
+	JOIN Suggestions s ON s.idSuggestion = es.idSuggestion
+	LEFT JOIN EntryType et ON et.idEntryType = e.idEntryType
+	WHERE NOT (COALESCE(et.type, '') = '` + EntryTypeRevert + `' AND es.isChosen = 0)
+
+	UNION ALL
+
+	SELECT
+		e.idEdit,
+		i.idSession,
+		datetime(i.timestamp),
+		'` + ActionNewRow + `',
+		s.idUniqueID,
+		s.idSuggestionType,
+		s.idProfile,
+		'',
+		s.suggestion
+	FROM Edit e
+	JOIN Interaction i ON i.idInteraction = e.IdInteraction
+	JOIN Edit_NewRow en ON en.idEdit = e.idEdit
+	JOIN Suggestions s ON s.idSuggestion = en.idSuggestion
+
+	UNION ALL
+
+	SELECT
+		e.idEdit,
+		i.idSession,
+		datetime(i.timestamp),
+		'` + ActionDelRow + `',
+		d.idUniqueID,
+		NULL,
+		NULL,
+		'',
+		'` + deletedRowValue + `'
+	FROM Edit e
+	JOIN Interaction i ON i.idInteraction = e.IdInteraction
+	JOIN Edit_DelRow d ON d.idEdit = e.idEdit
+
+	UNION ALL
+
+	SELECT
+		e.idEdit,
+		i.idSession,
+		datetime(i.timestamp),
+		'` + ActionRestoreRow + `',
+		r.idUniqueID,
+		NULL,
+		NULL,
+		'` + deletedRowValue + `',
+		''
+	FROM Edit e
+	JOIN Interaction i ON i.idInteraction = e.IdInteraction
+	JOIN Edit_RestoreRow r ON r.idEdit = e.idEdit
+`
+
+// model of the flattened history rows
+type historyRow struct {
+	IDEdit           int64
+	IDSession        int64
+	Timestamp        sql.NullString
+	Action           string
+	IDUniqueID       int64
+	IDSuggestionType sql.NullInt64
+	IDProfile        sql.NullInt64
+	ChangedFrom      string
+	ChangedTo        string
+}
+
+// model of a dataset column used to label rows
+type column struct {
+	IDSuggestionType int64
+	Name             string
+	Public           bool
+}
+
+// Query returns the page of history matching the filter, newest first, and the total number of matches.
+// usersDB resolves sessions to profiles and may be nil, in which case only the suggestion's profile is used.
+func Query(db, usersDB *sql.DB, f Filter) ([]Entry, int, error) {
+	// build the where clause from the filter
+	var conds []string
+	var args []interface{}
+	if f.IDUniqueID != 0 {
+		conds = append(conds, "h.idUniqueID = ?")
+		args = append(args, f.IDUniqueID)
+	}
+	if f.IDSuggestionType != 0 {
+		conds = append(conds, "h.idSuggestionType = ?")
+		args = append(args, f.IDSuggestionType)
+	}
+	if f.IDProfile != 0 {
+		// match the sessions of the profile as well as the suggestions it made
+		sessionIDs, err := profileSessions(usersDB, f.IDProfile)
+		if err != nil {
+			return nil, 0, err
+		}
+		cond := "h.idProfile = ?"
+		args = append(args, f.IDProfile)
+		if len(sessionIDs) > 0 {
+			cond += " OR h.idSession IN (" + placeholders(len(sessionIDs)) + ")"
+			for _, id := range sessionIDs {
+				args = append(args, id)
+			}
+		}
+		conds = append(conds, "("+cond+")")
+	}
+	if f.From != "" {
+		conds = append(conds, "h.timestamp >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		conds = append(conds, "h.timestamp <= ?")
+		args = append(args, f.To)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	// count every match for pagination
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM ("+historyQuery+") h"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count history: %w", err)
+	}
+
+	// get the requested page
+	query := "SELECT * FROM (" + historyQuery + ") h" + where + " ORDER BY h.timestamp DESC, h.idEdit DESC"
+	if f.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(f.Limit) + " OFFSET " + strconv.Itoa(f.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query history: %w", err)
+	}
+	defer rows.Close()
+
+	var page []historyRow
+	for rows.Next() {
+		var r historyRow
+		if err := rows.Scan(
+			&r.IDEdit,
+			&r.IDSession,
+			&r.Timestamp,
+			&r.Action,
+			&r.IDUniqueID,
+			&r.IDSuggestionType,
+			&r.IDProfile,
+			&r.ChangedFrom,
+			&r.ChangedTo,
+		); err != nil {
+			return nil, 0, fmt.Errorf("scan history row: %w", err)
+		}
+		page = append(page, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate history rows: %w", err)
+	}
+
+	// look up column names, row labels and profiles for the page
+	cols, err := loadColumns(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	columnNames := make(map[int64]string, len(cols))
+	for _, col := range cols {
+		columnNames[col.IDSuggestionType] = col.Name
+	}
+
+	uids := make([]int64, 0, len(page))
+	sessionIDs := make([]int64, 0, len(page))
+	seenUIDs := make(map[int64]bool)
+	seenSessions := make(map[int64]bool)
+	for _, r := range page {
+		if !seenUIDs[r.IDUniqueID] {
+			seenUIDs[r.IDUniqueID] = true
+			uids = append(uids, r.IDUniqueID)
+		}
+		if !seenSessions[r.IDSession] {
+			seenSessions[r.IDSession] = true
+			sessionIDs = append(sessionIDs, r.IDSession)
+		}
+	}
+
+	values, err := latestValues(db, uids)
+	if err != nil {
+		return nil, 0, err
+	}
+	sessionProfiles, err := sessionsProfiles(usersDB, sessionIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// turn each row into an entry
+	entries := make([]Entry, 0, len(page))
+	for _, r := range page {
+		entry := Entry{
+			IDEdit:       r.IDEdit,
+			IDUniqueID:   r.IDUniqueID,
+			When:         formatWhen(r.Timestamp.String),
+			Action:       r.Action,
+			WhoWasEdited: rowLabel(cols, values[r.IDUniqueID]),
+			ChangedFrom:  columns.DisplayValue(r.ChangedFrom),
+			ChangedTo:    columns.DisplayValue(r.ChangedTo),
+		}
+
+		// prefer the profile that owned the session, then the one that made the suggestion
+		if profileID, ok := sessionProfiles[r.IDSession]; ok {
+			entry.IDProfile = &profileID
+		} else if r.IDProfile.Valid {
+			profileID := r.IDProfile.Int64
+			entry.IDProfile = &profileID
+		}
+		entry.EditedBy = "anon"
+		if entry.IDProfile != nil {
+			entry.EditedBy += strconv.FormatInt(*entry.IDProfile, 10)
+		}
+
+		if r.IDSuggestionType.Valid {
+			idSuggestionType := r.IDSuggestionType.Int64
+			entry.IDSuggestionType = &idSuggestionType
+			entry.Column = columnNames[idSuggestionType]
+		} else if first := firstPublicColumn(cols); first != nil {
+			// a deleted or restored row is shown as a change to its first column
+			entry.Column = first.Name
+			if r.Action == ActionRestoreRow {
+				entry.ChangedTo = values[r.IDUniqueID][first.IDSuggestionType]
+			} else {
+				entry.ChangedFrom = values[r.IDUniqueID][first.IDSuggestionType]
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
+
+// function to get the columns of the dataset in column order
+func loadColumns(db *sql.DB) ([]column, error) {
+	rows, err := db.Query(`
+		SELECT idSuggestionType, COALESCE(name, ''), isActive = 1 AND isPrivate = 0
+		FROM SuggestionType
+		ORDER BY columnOrder IS NULL, columnOrder, idSuggestionType
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query SuggestionType: %w", err)
+	}
+	defer rows.Close()
+
+	var cols []column
+	for rows.Next() {
+		var col column
+		if err := rows.Scan(&col.IDSuggestionType, &col.Name, &col.Public); err != nil {
+			return nil, fmt.Errorf("scan suggestion type: %w", err)
+		}
+		cols = append(cols, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate suggestion types: %w", err)
+	}
+
+	return cols, nil
+}
+
+// function to get the latest value of every cell in the given rows, whether or not it's still active
+func latestValues(db *sql.DB, uids []int64) (map[int64]map[int64]string, error) {
+	values := make(map[int64]map[int64]string)
+	if len(uids) == 0 {
+		return values, nil
+	}
+
+	args := make([]interface{}, len(uids))
+	for i, id := range uids {
+		args[i] = id
+	}
+
+	// walk the suggestions from lowest to highest confidence so the last one seen wins
+	rows, err := db.Query(`
+		SELECT idUniqueID, idSuggestionType, suggestion
+		FROM Suggestions
+		WHERE idUniqueID IN (`+placeholders(len(uids))+`)
+		ORDER BY COALESCE(confidence, 0), idSuggestion
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query row values: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var uid, idSuggestionType int64
+		var suggestion string
+		if err := rows.Scan(&uid, &idSuggestionType, &suggestion); err != nil {
+			return nil, fmt.Errorf("scan row value: %w", err)
+		}
+		if values[uid] == nil {
+			values[uid] = make(map[int64]string)
+		}
+		values[uid][idSuggestionType] = columns.DisplayValue(suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate row values: %w", err)
+	}
+
+	return values, nil
+}
+
+// function to get the session ids of a profile from the users db
+func profileSessions(usersDB *sql.DB, idProfile int64) ([]int64, error) {
+	if usersDB == nil {
+		return nil, nil
+	}
+
+	rows, err := usersDB.Query("SELECT idSession FROM Session WHERE idProfile = ?", idProfile)
+	if err != nil {
+		return nil, fmt.Errorf("query profile sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan profile session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate profile sessions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// function to map session ids to their profile ids using the users db
+func sessionsProfiles(usersDB *sql.DB, sessionIDs []int64) (map[int64]int64, error) {
+	profiles := make(map[int64]int64)
+	if usersDB == nil || len(sessionIDs) == 0 {
+		return profiles, nil
+	}
+
+	args := make([]interface{}, len(sessionIDs))
+	for i, id := range sessionIDs {
+		args[i] = id
+	}
+
+	rows, err := usersDB.Query(
+		"SELECT idSession, idProfile FROM Session WHERE idProfile IS NOT NULL AND idSession IN ("+placeholders(len(sessionIDs))+")",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query session profiles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID, profileID int64
+		if err := rows.Scan(&sessionID, &profileID); err != nil {
+			return nil, fmt.Errorf("scan session profile: %w", err)
+		}
+		profiles[sessionID] = profileID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// function to find the first active, public column
+func firstPublicColumn(cols []column) *column {
+	for i := range cols {
+		if cols[i].Public {
+			return &cols[i]
+		}
+	}
+	return nil
+}
+
+// function to label a row by its first public column and the second one in parentheses, like "Name (University)"
+func rowLabel(cols []column, values map[int64]string) string {
+	var parts []string
+	for _, col := range cols {
+		if !col.Public {
+			continue
+		}
+		parts = append(parts, values[col.IDSuggestionType])
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	switch {
+	case len(parts) == 0:
+		return ""
+	case len(parts) == 1 || parts[1] == "":
+		return parts[0]
+	case parts[0] == "":
+		return parts[1]
+	default:
+		return parts[0] + " (" + parts[1] + ")"
+	}
+}
+
+// function to trim a timestamp down to the minute like the static history csv
+func formatWhen(timestamp string) string {
+	if len(timestamp) >= 16 {
+		return timestamp[:16]
+	}
+	return timestamp
+}
+
+// function to build n comma separated placeholders for an IN clause
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"drafty3/columns"
+	"drafty3/config"
+)
+
+// main function to read flags and error accordingly if issues and call run function for logic
+func main() {
+	// get flags and parse them
+	dbPath := flag.String("db", "", "Path to the dataset SQLite database file")
+	yamlPath := flag.String("yaml", "", "Path to the YAML column config, like public/csprofessors.yaml")
+	check := flag.Bool("check", false, "Only report where the YAML and the db disagree, without importing")
+	flag.Parse()
+
+	// make sure required flags are provided
+	if *dbPath == "" {
+		log.Fatal("missing required --db flag")
+	}
+	if *yamlPath == "" {
+		log.Fatal("missing required --yaml flag")
+	}
+
+	// call the run function for the logic
+	mismatches, err := run(*dbPath, *yamlPath, *check)
+	if err != nil {
+		log.Fatalf("import_columns failed: %v", err)
+	}
+
+	// exit non-zero if they still disagree so a check can fail a deploy
+	for _, m := range mismatches {
+		log.Printf("mismatch: %s", m)
+	}
+	if len(mismatches) > 0 {
+		log.Fatalf("%d mismatches between %s and %s", len(mismatches), *yamlPath, *dbPath)
+	}
+	log.Printf("%s and %s agree", *yamlPath, *dbPath)
+}
+
+// run function to import the hints of the YAML into the db, unless only checking, and compare the two
+func run(dbPath, yamlPath string, check bool) ([]columns.Mismatch, error) {
+	hints, err := columns.LoadYAML(yamlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// open the db, making sure it exists so sqlite doesn't create an empty one
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(dbPath)), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if !check {
+		updated, err := columns.Import(db, hints)
+		if err != nil {
+			return nil, fmt.Errorf("import: %w", err)
+		}
+		log.Printf("imported the hints of %d columns", updated)
+	}
+	return columns.Compare(db, hints)
+}
@@ -0,0 +1,298 @@
+// Package metrics keeps counters, histograms and gauges and writes them in the Prometheus text format,
+// so a scraper can read them without the server depending on a metrics library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the latency buckets in seconds, the same ones the Prometheus clients use
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is one value of a gauge read when the metrics are written, with its label values in label order
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Registry holds every metric in the order they were made
+type Registry struct {
+	mu      sync.Mutex
+	metrics []writer
+}
+
+// something that writes its own HELP, TYPE and samples
+type writer interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter makes a counter with the given label names and adds it to the registry
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: make(map[string]float64)}
+	r.add(c)
+	return c
+}
+
+// NewHistogram makes a histogram with the given upper bounds, in increasing order, and adds it to the registry
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, series: make(map[string]*histogramSeries)}
+	r.add(h)
+	return h
+}
+
+// NewGaugeFunc adds a gauge whose samples are read by collect every time the metrics are written.
+// a collect error is written as a comment so the rest of the metrics still go out.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() ([]Sample, error)) {
+	r.add(&gaugeFunc{desc: desc{name: name, help: help, labels: labels}, collect: collect})
+}
+
+// WriteText writes every metric in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]writer(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to add a metric to the registry
+func (r *Registry) add(m writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// COUNTER
+
+// Counter is a value per label set that only goes up
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Inc adds one to the series of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the series of the label values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for key, v := range c.values {
+		samples = append(samples, Sample{Labels: splitKey(key, len(c.labels)), Value: v})
+	}
+	c.mu.Unlock()
+
+	return c.writeSamples(w, "counter", samples)
+}
+
+// HISTOGRAM
+
+// Histogram counts observations into buckets per label set
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// counts of one label set, where counts[i] is the observations in bucket i alone
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records v in the series of the label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.writeHeader(w, "histogram"); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		labels := splitKey(key, len(h.labels))
+
+		// buckets are cumulative in the text format
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			if err := h.writeLine(w, "_bucket", labels, "le", formatFloat(bound), float64(cumulative)); err != nil {
+				return err
+			}
+		}
+		if err := h.writeLine(w, "_bucket", labels, "le", "+Inf", float64(s.count)); err != nil {
+			return err
+		}
+		if err := h.writeLine(w, "_sum", labels, "", "", s.sum); err != nil {
+			return err
+		}
+		if err := h.writeLine(w, "_count", labels, "", "", float64(s.count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GAUGE
+
+// gauge read from collect when written
+type gaugeFunc struct {
+	desc
+	collect func() ([]Sample, error)
+}
+
+func (g *gaugeFunc) write(w io.Writer) error {
+	samples, err := g.collect()
+	if err != nil {
+		if err := g.writeHeader(w, "gauge"); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "# ERROR %s: %s\n", g.name, strings.ReplaceAll(err.Error(), "\n", " "))
+		return err
+	}
+	return g.writeSamples(w, "gauge", samples)
+}
+
+// TEXT FORMAT
+
+// name, help and label names shared by every metric type
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// function to join label values into a map key, panicking on the wrong number like the Prometheus clients do
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// function to split a map key back into its label values
+func splitKey(key string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.SplitN(key, "\xff", n)
+}
+
+func (d *desc) writeHeader(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, kind)
+	return err
+}
+
+// function to write a counter or gauge sorted by label values
+func (d *desc) writeSamples(w io.Writer, kind string, samples []Sample) error {
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+
+	if err := d.writeHeader(w, kind); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		if err := d.writeLine(w, "", s.Labels, "", "", s.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to write one sample line, with an extra label like le when extraName is set
+func (d *desc) writeLine(w io.Writer, suffix string, labelValues []string, extraName, extraValue string, v float64) error {
+	var b strings.Builder
+	b.WriteString(d.name)
+	b.WriteString(suffix)
+
+	pairs := make([]string, 0, len(d.labels)+1)
+	for i, name := range d.labels {
+		pairs = append(pairs, name+`="`+escapeLabel(labelValues[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) > 0 {
+		b.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	b.WriteString(" " + formatFloat(v) + "\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// function to escape a label value, where backslash, quote and newline need escaping
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// function to escape help text, where only backslash and newline need escaping
+func escapeHelp(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v)
+}
+
+// function to format a value the way the text format spells infinities and NaN
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"drafty3/config"
+	"drafty3/migrations"
+)
+
+// a db to migrate and the migrations it takes
+type target struct {
+	name string
+	path string
+	set  migrations.Set
+}
+
+// main function to read flags and error accordingly if issues and call run function for logic
+func main() {
+	// get flags and parse them
+	dbRoot := flag.String("db_root", "", "Directory of the dataset databases and datasets.yaml, migrating the users db and every dataset")
+	dbPath := flag.String("db", "", "Path to one SQLite database file to migrate instead, created by up if it doesn't exist")
+	users := flag.Bool("users", false, "The --db file is a users db rather than a dataset db")
+	to := flag.Int("to", -1, "Version to migrate up or down to, defaulting to the newest for up and the one before the current for down")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [flags] [up|down|status]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// the command defaults to up
+	command := "up"
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		command = flag.Arg(0)
+	}
+	if command != "up" && command != "down" && command != "status" {
+		log.Fatalf("unknown command %q, want up, down or status", command)
+	}
+
+	// make sure exactly one way of picking the dbs is provided
+	if (*dbRoot == "") == (*dbPath == "") {
+		log.Fatal("need one of --db_root or --db")
+	}
+	targets, err := findTargets(*dbRoot, *dbPath, *users)
+	if err != nil {
+		log.Fatalf("migrate failed: %v", err)
+	}
+
+	// call the run function for the logic on every db, stopping at the first that fails
+	for _, t := range targets {
+		if err := run(command, t, *to); err != nil {
+			log.Fatalf("migrate %s failed: %v", t.name, err)
+		}
+	}
+}
+
+// function to list the dbs to migrate, either the one given or the users db and every dataset of the root
+func findTargets(dbRoot, dbPath string, users bool) ([]target, error) {
+	if dbPath != "" {
+		set := migrations.Dataset
+		if users {
+			set = migrations.Users
+		}
+		return []target{{name: filepath.Base(dbPath), path: dbPath, set: set}}, nil
+	}
+
+	targets := []target{{name: "users", path: filepath.Join(dbRoot, config.UsersDBFileName), set: migrations.Users}}
+	registry, err := config.LoadDatasets(dbRoot)
+	if err != nil {
+		return nil, fmt.Errorf("load datasets: %w", err)
+	}
+	for _, ds := range registry {
+		targets = append(targets, target{name: ds.Name, path: ds.Path, set: migrations.Dataset})
+	}
+	return targets, nil
+}
+
+// run function to open a db and migrate it up or down, or report its status
+func run(command string, t target, to int) error {
+	// only up makes a new db, so a mistyped path isn't silently created by the other commands
+	if _, err := os.Stat(t.path); errors.Is(err, fs.ErrNotExist) && command != "up" {
+		return fmt.Errorf("database %s doesn't exist", t.path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("database: %w", err)
+	}
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(t.path)), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	current, pending, err := migrations.Status(db, t.set)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "status":
+		log.Printf("%s is at version %d of %d", t.name, current, t.set.Latest())
+		for _, m := range pending {
+			log.Printf("%s pending: %d %s", t.name, m.Version, m.Name)
+		}
+		return nil
+
+	case "up":
+		if to < 0 {
+			to = 0
+		}
+		done, err := migrations.Up(db, t.set, to)
+		for _, m := range done {
+			log.Printf("%s applied: %d %s", t.name, m.Version, m.Name)
+		}
+		return err
+
+	default:
+		// without a version undo only the newest applied migration
+		if to < 0 {
+			records, err := migrations.Applied(db)
+			if err != nil {
+				return err
+			}
+			to = 0
+			if len(records) > 1 {
+				to = records[len(records)-2].Version
+			}
+		}
+		done, err := migrations.Down(db, t.set, to)
+		for _, m := range done {
+			log.Printf("%s undone: %d %s", t.name, m.Version, m.Name)
+		}
+		return err
+	}
+}
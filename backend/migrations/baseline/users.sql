@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS `Session` (`idSession` integer PRIMARY KEY AUTOINCREMENT,`idProfile` integer,`start` datetime DEFAULT CURRENT_TIMESTAMP,`end` datetime,CONSTRAINT `fk_Profile_sessions` FOREIGN KEY (`idProfile`) REFERENCES `Profile`(`idProfile`));
+CREATE INDEX IF NOT EXISTS `fk_Session_Profile1_idx` ON `Session`(`idProfile`);
+CREATE TABLE IF NOT EXISTS `Profile` (`idProfile` integer PRIMARY KEY AUTOINCREMENT,`date_created` datetime DEFAULT CURRENT_TIMESTAMP,`date_updated` datetime DEFAULT CURRENT_TIMESTAMP);
@@ -0,0 +1,258 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// lookup tables that rows are soft deleted from
+var softDeletedTables = []string{
+	"DataType",
+	"DatabaitCreateType",
+	"DatabaitNextAction",
+	"DatabaitTemplateType",
+	"EntryType",
+	"InteractionType",
+	"Role",
+	"SearchType",
+}
+
+// presentation hints of a column, stored on its SuggestionType
+var suggestionTypeHintColumns = []string{"valueType", "width", "editMode"}
+
+// lookup rows the frontend records interactions with, looked up by name but seeded with the ids it used to send,
+// and the entry types the backend records reverts and row restores under
+var seededLookups = []struct {
+	table, id, column string
+	rows              map[int64]string
+}{
+	{"InteractionType", "idInteractionType", "interaction", map[int64]string{
+		1: "click", 2: "edit", 3: "new row", 4: "delete row", 5: "search",
+	}},
+	{"EntryType", "idEntryType", "type", map[int64]string{
+		1: "edit", 2: "new row", 3: "delete row", 4: "revert", 5: "restore row",
+	}},
+	{"SearchType", "idSearchType", "type", map[int64]string{
+		1: "column",
+	}},
+}
+
+// the columns of each dataset in the order the grid and the CSV showed them before columnOrder was set, which the
+// csprofs CSV header and the YAML column configs hard coded
+var baselineColumnLayouts = [][]string{
+	{"FullName", "University", "JoinYear", "SubField", "Bachelors", "Doctorate"},
+	{"Name", "Major", "GradYear"},
+}
+
+// the presentation hints of each dataset's columns as the YAML column configs in public/ had them when the hints
+// moved into the dataset dbs
+var baselineColumnHints = map[string]struct{ valueType, width, editMode string }{
+	"FullName":   {"string", "15%", "free_text"},
+	"University": {"string", "20%", "dropdown"},
+	"JoinYear":   {"string", "6%", "dropdown"},
+	"SubField":   {"string[]", "18%", "multi_select"},
+	"Bachelors":  {"string", "20%", "dropdown_free_text"},
+	"Doctorate":  {"string", "20%", "dropdown_free_text"},
+	"Name":       {"string", "40%", "free_text"},
+	"Major":      {"string", "40%", "dropdown_free_text"},
+	"GradYear":   {"string", "18%", "dropdown"},
+}
+
+// Dataset is the migrations of the dataset dbs. the first creates the baseline tables the dbs had before
+// migrations, so a change to a model needs a migration that makes it, skipping what's there already.
+var Dataset = Set{
+	{
+		Version: 1,
+		Name:    "create tables",
+		Up: func(tx *gorm.DB) error {
+			return createBaseline(tx, "dataset.sql")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropBaseline(tx, "dataset.sql")
+		},
+	},
+	{
+		Version: 2,
+		Name:    "soft delete lookup rows",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range softDeletedTables {
+				if err := addColumn(tx, table, "deleted", "datetime"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range softDeletedTables {
+				if err := dropColumn(tx, table, "deleted"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "suggestion type hints",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range suggestionTypeHintColumns {
+				if err := addColumn(tx, "SuggestionType", column, "text"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range suggestionTypeHintColumns {
+				if err := dropColumn(tx, "SuggestionType", column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "seed lookup rows",
+		Up: func(tx *gorm.DB) error {
+			// rows that exist keep whatever name they have
+			for _, lookup := range seededLookups {
+				for id, name := range lookup.rows {
+					if err := tx.Exec("INSERT OR IGNORE INTO `"+lookup.table+"` (`"+lookup.id+"`, `"+lookup.column+"`) VALUES (?, ?)",
+						id, name).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// only rows still named as they were seeded are removed
+			for _, lookup := range seededLookups {
+				for id, name := range lookup.rows {
+					if err := tx.Exec("DELETE FROM `"+lookup.table+"` WHERE `"+lookup.id+"` = ? AND `"+lookup.column+"` = ?",
+						id, name).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 5,
+		Name:    "remove user data completed",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumn(tx, "RemoveUserData", "completed", "datetime"); err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX IF NOT EXISTS `index_completed_removeuserdata` ON `RemoveUserData`(`completed`)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropIndex(tx, "index_completed_removeuserdata"); err != nil {
+				return err
+			}
+			return dropColumn(tx, "RemoveUserData", "completed")
+		},
+	},
+	{
+		Version: 6,
+		Name:    "one active suggestion per cell",
+		Up: func(tx *gorm.DB) error {
+			// the active suggestion a cell shows stays active, picked by confidence then newest like the grid and
+			// the CSV pick it, so no cell changes. the others are deactivated, which can't be undone
+			if err := tx.Exec(`UPDATE Suggestions SET active = 0
+				WHERE active = 1 AND idSuggestion NOT IN (
+					SELECT idSuggestion FROM (
+						SELECT idSuggestion, ROW_NUMBER() OVER (
+							PARTITION BY idSuggestionType, idUniqueID
+							ORDER BY COALESCE(confidence, 0) DESC, idSuggestion DESC
+						) AS rank
+						FROM Suggestions WHERE active = 1
+					) WHERE rank = 1
+				)`).Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS `ux_Suggestions_active_cell` ON `Suggestions`(`idSuggestionType`,`idUniqueID`) WHERE active = 1").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropIndex(tx, "ux_Suggestions_active_cell")
+		},
+	},
+	{
+		Version: 7,
+		Name:    "restore row edits",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE IF NOT EXISTS `Edit_RestoreRow` (`idUniqueID` integer NOT NULL,`idEdit` integer NOT NULL,`comment` text NOT NULL)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS `Edit_RestoreRow`").Error
+		},
+	},
+	{
+		Version: 8,
+		Name:    "backfill column order",
+		Up: func(tx *gorm.DB) error {
+			// columns that have an order keep it
+			for _, layout := range baselineColumnLayouts {
+				for i, name := range layout {
+					if err := tx.Exec("UPDATE SuggestionType SET columnOrder = ? WHERE columnOrder IS NULL AND LOWER(name) = LOWER(?)",
+						i+1, name).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// only orders still as they were backfilled are cleared
+			for _, layout := range baselineColumnLayouts {
+				for i, name := range layout {
+					if err := tx.Exec("UPDATE SuggestionType SET columnOrder = NULL WHERE columnOrder = ? AND LOWER(name) = LOWER(?)",
+						i+1, name).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 9,
+		Name:    "backfill column hints",
+		Up: func(tx *gorm.DB) error {
+			// columns that have any hint keep theirs, as they were imported or set by hand
+			for name, hints := range baselineColumnHints {
+				if err := tx.Exec(`UPDATE SuggestionType SET valueType = ?, width = ?, editMode = ?
+					WHERE valueType IS NULL AND width IS NULL AND editMode IS NULL AND LOWER(name) = LOWER(?)`,
+					hints.valueType, hints.width, hints.editMode, name).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// only hints still as they were backfilled are cleared
+			for name, hints := range baselineColumnHints {
+				if err := tx.Exec(`UPDATE SuggestionType SET valueType = NULL, width = NULL, editMode = NULL
+					WHERE valueType = ? AND width = ? AND editMode = ? AND LOWER(name) = LOWER(?)`,
+					hints.valueType, hints.width, hints.editMode, name).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 10,
+		Name:    "row delete suggestions",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE IF NOT EXISTS `Edit_DelRow_Suggestion` (`idEdit` integer NOT NULL,`idSuggestion` integer NOT NULL)").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX IF NOT EXISTS `index_edit_delrow_suggestion_idEdit` ON `Edit_DelRow_Suggestion`(`idEdit`)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS `Edit_DelRow_Suggestion`").Error
+		},
+	},
+}
@@ -0,0 +1,270 @@
+// Package migrations applies the versioned schema changes of the users db and the dataset dbs. every change
+// is a Migration with an up and a down step, and the versions a db has applied are recorded in its
+// schema_migrations table, so a db can be brought to any version and a binary can tell when a db was migrated
+// by a newer one.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableName is the table each db records its applied migrations in
+const TableName = "schema_migrations"
+
+// the schema of the dbs from before migrations, one statement per line
+//
+//go:embed baseline/*.sql
+var baselineFS embed.FS
+
+// the table a line of a baseline file creates
+var baselineTablePattern = regexp.MustCompile("^CREATE TABLE IF NOT EXISTS `([^`]+)`")
+
+// ErrAhead is returned when a db has applied migrations the binary doesn't know, so it was migrated by a
+// newer binary whose schema this one can't be trusted to use
+var ErrAhead = errors.New("db is ahead of this binary")
+
+// Migration is one versioned change to a db's schema or seed rows
+type Migration struct {
+	Version int
+	Name    string
+	// Up makes the change, and Down undoes it, each in the transaction that records it
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+}
+
+// Set is the migrations of one kind of db, in version order
+type Set []Migration
+
+// Record is a row of schema_migrations
+type Record struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"column:name;not null" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
+
+func (Record) TableName() string { return TableName }
+
+// Latest returns the version of the newest migration, which a fully migrated db is at
+func (s Set) Latest() int {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[len(s)-1].Version
+}
+
+// function to check versions only go up and every migration can be applied and undone
+func (s Set) validate() error {
+	for i, m := range s {
+		if m.Version <= 0 || (i > 0 && m.Version <= s[i-1].Version) {
+			return fmt.Errorf("migration %d %s is out of order", m.Version, m.Name)
+		}
+		if m.Up == nil || m.Down == nil {
+			return fmt.Errorf("migration %d %s needs both an up and a down step", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Applied returns the migrations recorded in db in version order, which is none before the first Up
+func Applied(db *gorm.DB) ([]Record, error) {
+	if !db.Migrator().HasTable(&Record{}) {
+		return nil, nil
+	}
+	var records []Record
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("read %s: %w", TableName, err)
+	}
+	return records, nil
+}
+
+// Status returns the version db is at and the migrations it still needs, failing with ErrAhead if it has
+// applied a migration the set doesn't have
+func Status(db *gorm.DB, set Set) (int, []Migration, error) {
+	if err := set.validate(); err != nil {
+		return 0, nil, err
+	}
+	records, err := Applied(db)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	known := make(map[int]bool, len(set))
+	for _, m := range set {
+		known[m.Version] = true
+	}
+	applied := make(map[int]bool, len(records))
+	current := 0
+	for _, r := range records {
+		if !known[r.Version] {
+			return 0, nil, fmt.Errorf("%w: migration %d %s was applied but this binary only knows up to %d",
+				ErrAhead, r.Version, r.Name, set.Latest())
+		}
+		applied[r.Version] = true
+		current = max(current, r.Version)
+	}
+
+	var pending []Migration
+	for _, m := range set {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return current, pending, nil
+}
+
+// Up applies every pending migration up to target, or all of them if target is 0, each in its own
+// transaction, and returns the ones it applied
+func Up(db *gorm.DB, set Set, target int) ([]Migration, error) {
+	_, pending, err := Status(db, set)
+	if err != nil {
+		return nil, err
+	}
+	if target == 0 {
+		target = set.Latest()
+	}
+	if err := createMissingTables(db, &Record{}); err != nil {
+		return nil, fmt.Errorf("create %s: %w", TableName, err)
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		if m.Version > target {
+			break
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		}); err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down undoes the applied migrations above target, newest first, each in its own transaction, and returns
+// the ones it undid
+func Down(db *gorm.DB, set Set, target int) ([]Migration, error) {
+	_, pending, err := Status(db, set)
+	if err != nil {
+		return nil, err
+	}
+	isPending := make(map[int]bool, len(pending))
+	for _, m := range pending {
+		isPending[m.Version] = true
+	}
+
+	var done []Migration
+	for i := len(set) - 1; i >= 0; i-- {
+		m := set[i]
+		if m.Version <= target {
+			break
+		}
+		if isPending[m.Version] {
+			continue
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&Record{}, m.Version).Error
+		}); err != nil {
+			return done, fmt.Errorf("undo migration %d %s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// function to read the statements of a baseline file
+func baselineStatements(name string) ([]string, error) {
+	data, err := baselineFS.ReadFile("baseline/" + name)
+	if err != nil {
+		return nil, err
+	}
+	var statements []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			statements = append(statements, line)
+		}
+	}
+	return statements, nil
+}
+
+// function to create the baseline tables and indexes that are missing. the baseline is frozen as the dbs were
+// before migrations, so it never changes with the models and every later change is a migration of its own
+func createBaseline(tx *gorm.DB, name string) error {
+	statements, err := baselineStatements(name)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to drop the baseline tables, last created first
+func dropBaseline(tx *gorm.DB, name string) error {
+	statements, err := baselineStatements(name)
+	if err != nil {
+		return err
+	}
+	for i := len(statements) - 1; i >= 0; i-- {
+		match := baselineTablePattern.FindStringSubmatch(statements[i])
+		if match == nil {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", match[1])).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to create the tables of the models that don't have one yet, leaving existing tables as they
+// are so a db made before migrations isn't altered by AutoMigrate's guesses
+func createMissingTables(tx *gorm.DB, models ...interface{}) error {
+	for _, model := range models {
+		if tx.Migrator().HasTable(model) {
+			continue
+		}
+		if err := tx.Migrator().CreateTable(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// function to add a column unless it's there already, as it is on a db whose tables were created from the
+// models rather than the baseline
+func addColumn(tx *gorm.DB, table, column, columnType string) error {
+	if tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column, columnType)).Error
+}
+
+// function to drop an index if it's there
+func dropIndex(tx *gorm.DB, name string) error {
+	return tx.Exec(fmt.Sprintf("DROP INDEX IF EXISTS `%s`", name)).Error
+}
+
+// function to drop a column, which sqlite does in place since 3.35
+func dropColumn(tx *gorm.DB, table, column string) error {
+	if !tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`", table, column)).Error
+}
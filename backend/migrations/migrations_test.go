@@ -0,0 +1,299 @@
+package migrations
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/config"
+	"drafty3/go_migration/data_model"
+	"drafty3/go_migration/user_model"
+)
+
+// every table of a dataset db
+var datasetModels = []interface{}{
+	&data_model.Alias{},
+	&data_model.Click{},
+	&data_model.DataType{},
+	&data_model.DatabaitCreateType{},
+	&data_model.DatabaitNextAction{},
+	&data_model.DatabaitTemplateType{},
+	&data_model.DoubleClick{},
+	&data_model.EditSuggestion{},
+	&data_model.EntryType{},
+	&data_model.Interaction{},
+	&data_model.DatabaitTweet{},
+	&data_model.Edit{},
+	&data_model.InteractionType{},
+	&data_model.Profile{},
+	&data_model.RemoveUserData{},
+	&data_model.Role{},
+	&data_model.SearchType{},
+	&data_model.SelectRange{},
+	&data_model.Session{},
+	&data_model.SuggestionType{},
+	&data_model.CopyColumn{},
+	&data_model.Search{},
+	&data_model.SearchMulti{},
+	&data_model.Sort{},
+	&data_model.SuggestionTypeValues{},
+	&data_model.UniqueId{},
+	&data_model.Comments{},
+	&data_model.CommentVote{},
+	&data_model.CommentsView{},
+	&data_model.Databaits{},
+	&data_model.DatabaitVisit{},
+	&data_model.EditDelRow{},
+	&data_model.EditDelRowSuggestion{},
+	&data_model.EditRestoreRow{},
+	&data_model.HelpUs{},
+	&data_model.Suggestions{},
+	&data_model.Copy{},
+	&data_model.EditNewRow{},
+	&data_model.Paste{},
+	&data_model.SearchGoogle{},
+	&data_model.ViewChange{},
+	&data_model.Visit{},
+	&data_model.Sessions{},
+}
+
+// every table of the users db
+var usersModels = []interface{}{
+	&user_model.Session{},
+	&user_model.Profile{},
+	&user_model.Role{},
+	&user_model.Sessions{},
+}
+
+// openTestDB opens a db file in a temp dir the way the server opens it
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(dbPath)), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// checkMatchesModels fails for every column and index of the models that db doesn't have
+func checkMatchesModels(t *testing.T, db *gorm.DB, models []interface{}) {
+	t.Helper()
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(table, field.DBName) {
+				t.Errorf("column %s.%s is missing", table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if !db.Migrator().HasIndex(table, index.Name) {
+				t.Errorf("index %s on %s is missing", index.Name, table)
+			}
+		}
+	}
+}
+
+// TestUpMatchesModels migrates a db at the baseline, as the dbs were before migrations, and an empty db, and
+// checks both end up with every column and index of the models
+func TestUpMatchesModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      Set
+		baseline string
+		models   []interface{}
+	}{
+		{"dataset", Dataset, "dataset.sql", datasetModels},
+		{"users", Users, "users.sql", usersModels},
+	}
+	for _, tt := range tests {
+		for _, fromBaseline := range []bool{true, false} {
+			name := tt.name + " empty"
+			if fromBaseline {
+				name = tt.name + " baseline"
+			}
+			t.Run(name, func(t *testing.T) {
+				db := openTestDB(t)
+				if fromBaseline {
+					if err := createBaseline(db, tt.baseline); err != nil {
+						t.Fatalf("create baseline: %v", err)
+					}
+				}
+
+				done, err := Up(db, tt.set, 0)
+				if err != nil {
+					t.Fatalf("up: %v", err)
+				}
+				if len(done) != len(tt.set) {
+					t.Fatalf("applied %d migrations, want %d", len(done), len(tt.set))
+				}
+				checkMatchesModels(t, db, tt.models)
+
+				current, pending, err := Status(db, tt.set)
+				if err != nil {
+					t.Fatalf("status: %v", err)
+				}
+				if current != tt.set.Latest() || len(pending) != 0 {
+					t.Fatalf("status = %d with %d pending, want %d with none", current, len(pending), tt.set.Latest())
+				}
+			})
+		}
+	}
+}
+
+// TestDownAndUpAgain undoes every migration of a migrated db and applies them again
+func TestDownAndUpAgain(t *testing.T) {
+	tests := []struct {
+		name   string
+		set    Set
+		models []interface{}
+	}{
+		{"dataset", Dataset, datasetModels},
+		{"users", Users, usersModels},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if _, err := Up(db, tt.set, 0); err != nil {
+				t.Fatalf("up: %v", err)
+			}
+
+			done, err := Down(db, tt.set, 0)
+			if err != nil {
+				t.Fatalf("down: %v", err)
+			}
+			if len(done) != len(tt.set) {
+				t.Fatalf("undid %d migrations, want %d", len(done), len(tt.set))
+			}
+			tables, err := db.Migrator().GetTables()
+			if err != nil {
+				t.Fatalf("list tables: %v", err)
+			}
+			for _, table := range tables {
+				if table != TableName && table != "sqlite_sequence" {
+					t.Errorf("table %s is left after down", table)
+				}
+			}
+
+			if _, err := Up(db, tt.set, 0); err != nil {
+				t.Fatalf("up again: %v", err)
+			}
+			checkMatchesModels(t, db, tt.models)
+		})
+	}
+}
+
+// TestActiveCellDeduplicated checks the unique active cell index is added to a baseline db with cells that have
+// two active suggestions, keeping the one the grid shows, which is the highest confidence then the newest
+func TestActiveCellDeduplicated(t *testing.T) {
+	db := openTestDB(t)
+	if err := createBaseline(db, "dataset.sql"); err != nil {
+		t.Fatalf("create baseline: %v", err)
+	}
+	for _, s := range []struct {
+		idUniqueID int
+		suggestion string
+		confidence int
+	}{
+		// the newest suggestion of row 1 has a lower confidence, and the ones of row 2 tie
+		{1, "shown", 5},
+		{1, "newer", 2},
+		{2, "older", 3},
+		{2, "shown", 3},
+		{3, "only", 1},
+	} {
+		if err := db.Exec("INSERT INTO Suggestions (idSuggestionType, idUniqueID, suggestion, active, confidence) VALUES (1, ?, ?, 1, ?)",
+			s.idUniqueID, s.suggestion, s.confidence).Error; err != nil {
+			t.Fatalf("seed suggestion: %v", err)
+		}
+	}
+
+	if _, err := Up(db, Dataset, 0); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	var active []string
+	if err := db.Raw("SELECT suggestion FROM Suggestions WHERE active = 1 ORDER BY idUniqueID").Scan(&active).Error; err != nil {
+		t.Fatalf("read suggestions: %v", err)
+	}
+	if strings.Join(active, ",") != "shown,shown,only" {
+		t.Fatalf("active suggestions = %v, want [shown shown only]", active)
+	}
+	if err := db.Exec("INSERT INTO Suggestions (idSuggestionType, idUniqueID, suggestion, active) VALUES (1, 1, 'again', 1)").Error; err == nil {
+		t.Fatal("a second active suggestion for a cell was inserted")
+	}
+}
+
+// seedCSProfsColumns adds the columns of the csprofs db as they were before migrations, in id order
+func seedCSProfsColumns(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	for id, name := range map[int]string{1: "FullName", 2: "University", 3: "Bachelors", 5: "Doctorate", 7: "JoinYear", 9: "SubField"} {
+		if err := db.Exec("INSERT INTO SuggestionType (idSuggestionType, idDataType, name) VALUES (?, 1, ?)", id, name).Error; err != nil {
+			t.Fatalf("seed column %s: %v", name, err)
+		}
+	}
+}
+
+// TestColumnLayoutBackfilled checks a baseline db gets the column order its CSV had and the hints of its YAML,
+// which undoing clears
+func TestColumnLayoutBackfilled(t *testing.T) {
+	db := openTestDB(t)
+	if err := createBaseline(db, "dataset.sql"); err != nil {
+		t.Fatalf("create baseline: %v", err)
+	}
+	seedCSProfsColumns(t, db)
+
+	if _, err := Up(db, Dataset, 0); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	var names []string
+	if err := db.Raw("SELECT name FROM SuggestionType ORDER BY columnOrder IS NULL, columnOrder, idSuggestionType").
+		Scan(&names).Error; err != nil {
+		t.Fatalf("read columns: %v", err)
+	}
+	want := []string{"FullName", "University", "JoinYear", "SubField", "Bachelors", "Doctorate"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("columns in order = %v, want %v", names, want)
+	}
+
+	var hints struct{ ValueType, Width, EditMode string }
+	if err := db.Raw("SELECT valueType AS value_type, width, editMode AS edit_mode FROM SuggestionType WHERE name = 'SubField'").
+		Scan(&hints).Error; err != nil {
+		t.Fatalf("read hints: %v", err)
+	}
+	if hints.ValueType != "string[]" || hints.Width != "18%" || hints.EditMode != "multi_select" {
+		t.Fatalf("SubField hints = %+v, want string[] 18%% multi_select", hints)
+	}
+
+	if _, err := Down(db, Dataset, 7); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	var laidOut int64
+	if err := db.Raw("SELECT COUNT(*) FROM SuggestionType WHERE columnOrder IS NOT NULL OR valueType IS NOT NULL").
+		Scan(&laidOut).Error; err != nil {
+		t.Fatalf("count laid out columns: %v", err)
+	}
+	if laidOut != 0 {
+		t.Fatalf("%d columns still have an order or hints after down", laidOut)
+	}
+}
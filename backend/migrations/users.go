@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/user_model"
+)
+
+// roles every users db has, where new profiles get idRole 2
+var seededRoles = []user_model.Role{
+	{IDRole: 1, Role: "admin"},
+	{IDRole: 2, Role: "user"},
+	{IDRole: 3, Role: "moderator"},
+}
+
+// columns a profile needs to be an account
+var profileAccountColumns = []struct{ column, columnType string }{
+	{"username", "text"},
+	{"email", "text"},
+	{"password", "text"},
+	// the role isn't a foreign key, since sqlite can't add one with a default to an existing table
+	{"idRole", "integer NOT NULL DEFAULT 2"},
+}
+
+// indexes of the profile account columns
+var profileAccountIndexes = []struct{ name, definition string }{
+	{"index_idRole_profileTable", "CREATE INDEX IF NOT EXISTS `index_idRole_profileTable` ON `Profile`(`idRole`)"},
+	{"unique_username_profile", "CREATE UNIQUE INDEX IF NOT EXISTS `unique_username_profile` ON `Profile`(`username`)"},
+	{"unique_email_profile", "CREATE UNIQUE INDEX IF NOT EXISTS `unique_email_profile` ON `Profile`(`email`)"},
+}
+
+// Users is the migrations of the users db, where the first creates the baseline tables like the first of
+// Dataset
+var Users = Set{
+	{
+		Version: 1,
+		Name:    "create tables",
+		Up: func(tx *gorm.DB) error {
+			return createBaseline(tx, "users.sql")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropBaseline(tx, "users.sql")
+		},
+	},
+	{
+		Version: 2,
+		Name:    "role table",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE IF NOT EXISTS `Role` (`idRole` integer PRIMARY KEY AUTOINCREMENT,`role` text NOT NULL)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS `Role`").Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "seed roles",
+		Up: func(tx *gorm.DB) error {
+			for _, role := range seededRoles {
+				if err := tx.FirstOrCreate(&role, user_model.Role{IDRole: role.IDRole}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, role := range seededRoles {
+				if err := tx.Where("idRole = ? AND role = ?", role.IDRole, role.Role).Delete(&user_model.Role{}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "profile accounts",
+		Up: func(tx *gorm.DB) error {
+			for _, c := range profileAccountColumns {
+				if err := addColumn(tx, "Profile", c.column, c.columnType); err != nil {
+					return err
+				}
+			}
+			for _, index := range profileAccountIndexes {
+				if err := tx.Exec(index.definition).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// the indexes go first, since sqlite won't drop an indexed column
+			for _, index := range profileAccountIndexes {
+				if err := dropIndex(tx, index.name); err != nil {
+					return err
+				}
+			}
+			for _, c := range profileAccountColumns {
+				if err := dropColumn(tx, "Profile", c.column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 5,
+		Name:    "sessions table",
+		Up: func(tx *gorm.DB) error {
+			for _, statement := range []string{
+				"CREATE TABLE IF NOT EXISTS `sessions` (`session_id` integer PRIMARY KEY AUTOINCREMENT,`idProfile` integer,`expires` integer NOT NULL,`data` text)",
+				"CREATE INDEX IF NOT EXISTS `index_expires_sessions` ON `sessions`(`expires`)",
+				"CREATE INDEX IF NOT EXISTS `index_idProfile_sessions` ON `sessions`(`idProfile`)",
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS `sessions`").Error
+		},
+	},
+}
@@ -0,0 +1,215 @@
+// Package removal carries out the RemoveUserData requests of every dataset db. a profile's tracking data is
+// deleted, the interactions behind its edits are kept under a tombstone profile so the edit history still
+// adds up, and the profile with its sessions is removed from the users db.
+package removal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/data_model"
+	"drafty3/go_migration/user_model"
+)
+
+// username of the profile that removed profiles' edits and suggestions are handed to.
+// it doesn't match the signup username pattern and has no password so nobody can sign up or log in as it.
+const TombstoneUsername = "[deleted]"
+
+// Dataset is a dataset db whose RemoveUserData requests are processed
+type Dataset struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// Result is what a run removed
+type Result struct {
+	// Profiles are the profiles that were removed, in order
+	Profiles []int64 `json:"profiles"`
+	// Requests is how many RemoveUserData rows were marked completed across the datasets
+	Requests int64 `json:"requests"`
+}
+
+// tables that only record what someone looked at or searched for, deleted along with the interaction
+var trackingTables = []interface{}{
+	&data_model.Click{},
+	&data_model.DoubleClick{},
+	&data_model.SelectRange{},
+	&data_model.Search{},
+	&data_model.SearchMulti{},
+	&data_model.Sort{},
+	&data_model.Copy{},
+	&data_model.Paste{},
+	&data_model.CopyColumn{},
+	&data_model.SearchGoogle{},
+	&data_model.CommentsView{},
+	&data_model.CommentVote{},
+	&data_model.ViewChange{},
+	&data_model.Visit{},
+	&data_model.DatabaitVisit{},
+}
+
+// Run processes every pending RemoveUserData request, marking them completed at now.
+// a failure stops the run, and requests that weren't marked completed are picked up again by the next run.
+func Run(usersDB *gorm.DB, datasets []Dataset, now time.Time) (Result, error) {
+	result := Result{Profiles: []int64{}}
+
+	// collect the profiles with a pending request in any dataset
+	pending := make(map[int64]bool)
+	for _, ds := range datasets {
+		var ids []int64
+		if err := ds.DB.Model(&data_model.RemoveUserData{}).
+			Where("completed IS NULL").
+			Distinct().
+			Pluck("id_profile", &ids).Error; err != nil {
+			return result, fmt.Errorf("find %s requests: %w", ds.Name, err)
+		}
+		for _, id := range ids {
+			pending[id] = true
+		}
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+	profileIDs := make([]int64, 0, len(pending))
+	for id := range pending {
+		profileIDs = append(profileIDs, id)
+	}
+	sort.Slice(profileIDs, func(i, j int) bool { return profileIDs[i] < profileIDs[j] })
+
+	// find or create the tombstone and the session its interactions are kept under
+	tombstone, tombstoneSession, err := ensureTombstone(usersDB, now)
+	if err != nil {
+		return result, fmt.Errorf("tombstone profile: %w", err)
+	}
+
+	for _, profileID := range profileIDs {
+		// the tombstone can't be removed into itself so only its requests are closed
+		if profileID != tombstone.IDProfile {
+			if err := removeProfile(usersDB, datasets, profileID, tombstone.IDProfile, tombstoneSession.IDSession); err != nil {
+				return result, fmt.Errorf("remove profile %d: %w", profileID, err)
+			}
+			result.Profiles = append(result.Profiles, profileID)
+		}
+
+		// mark the profile's requests in every dataset completed
+		for _, ds := range datasets {
+			marked := ds.DB.Model(&data_model.RemoveUserData{}).
+				Where("id_profile = ? AND completed IS NULL", profileID).
+				Update("completed", now)
+			if marked.Error != nil {
+				return result, fmt.Errorf("complete %s requests of profile %d: %w", ds.Name, profileID, marked.Error)
+			}
+			result.Requests += marked.RowsAffected
+		}
+	}
+
+	return result, nil
+}
+
+// function to remove a profile's data from every dataset and then the profile itself from the users db
+func removeProfile(usersDB *gorm.DB, datasets []Dataset, profileID, tombstoneID, tombstoneSessionID int64) error {
+	// interactions are recorded against sessions so find the profile's sessions first
+	var sessionIDs []int64
+	if err := usersDB.Model(&user_model.Session{}).
+		Where("idProfile = ?", profileID).
+		Pluck("idSession", &sessionIDs).Error; err != nil {
+		return err
+	}
+
+	// clean the datasets before the profile goes so a failure can be retried with the same sessions
+	for _, ds := range datasets {
+		if err := ds.DB.Transaction(func(tx *gorm.DB) error {
+			return removeDatasetData(tx, profileID, sessionIDs, tombstoneID, tombstoneSessionID)
+		}); err != nil {
+			return fmt.Errorf("%s: %w", ds.Name, err)
+		}
+	}
+
+	// then remove the stored cookie sessions, the sessions and the profile
+	return usersDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user_model.Sessions{}, "idProfile = ?", profileID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&user_model.Session{}, "idProfile = ?", profileID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user_model.Profile{}, "idProfile = ?", profileID).Error
+	})
+}
+
+// function to remove a profile's data from one dataset db
+func removeDatasetData(tx *gorm.DB, profileID int64, sessionIDs []int64, tombstoneID, tombstoneSessionID int64) error {
+	// suggestions are the dataset's content so they stay and are credited to the tombstone
+	if err := tx.Model(&data_model.Suggestions{}).
+		Where("idProfile = ?", profileID).
+		Update("idProfile", tombstoneID).Error; err != nil {
+		return err
+	}
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	// the profile's interactions, built fresh for each statement that uses it
+	interactions := func() *gorm.DB {
+		return tx.Model(&data_model.Interaction{}).Select("idInteraction").Where("idSession IN ?", sessionIDs)
+	}
+
+	// delete the comments the profile wrote along with the votes on them, then its tracking rows
+	if err := tx.Where("idComment IN (?)",
+		tx.Model(&data_model.Comments{}).Select("idComment").Where("idInteraction IN (?)", interactions()),
+	).Delete(&data_model.CommentVote{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("idInteraction IN (?)", interactions()).Delete(&data_model.Comments{}).Error; err != nil {
+		return err
+	}
+	for _, table := range trackingTables {
+		if err := tx.Where("idInteraction IN (?)", interactions()).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+
+	// interactions behind edits, databaits and help us answers are part of the dataset's history,
+	// so they move to the tombstone's session and the rest are deleted
+	kept := "idInteraction IN (SELECT IdInteraction FROM Edit) OR " +
+		"idInteraction IN (SELECT idInteraction FROM Databaits) OR " +
+		"idInteraction IN (SELECT idInteraction FROM DatabaitTweet) OR " +
+		"idInteraction IN (SELECT idInteraction FROM HelpUs)"
+	if err := tx.Where("idSession IN ?", sessionIDs).
+		Where("NOT (" + kept + ")").
+		Delete(&data_model.Interaction{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&data_model.Interaction{}).
+		Where("idSession IN ?", sessionIDs).
+		Update("idSession", tombstoneSessionID).Error
+}
+
+// function to find or create the tombstone profile and its session
+func ensureTombstone(usersDB *gorm.DB, now time.Time) (user_model.Profile, user_model.Session, error) {
+	var profile user_model.Profile
+	var session user_model.Session
+
+	err := usersDB.Transaction(func(tx *gorm.DB) error {
+		username := TombstoneUsername
+		if err := tx.Where(user_model.Profile{Username: &username}).
+			Attrs(user_model.Profile{DateCreated: now, DateUpdated: now}).
+			FirstOrCreate(&profile).Error; err != nil {
+			return err
+		}
+
+		// a session that's already over, only there so kept interactions still point at a session
+		err := tx.Where("idProfile = ?", profile.IDProfile).Order("idSession").First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			session = user_model.Session{IDProfile: profile.IDProfile, Start: now, End: now}
+			return tx.Create(&session).Error
+		}
+		return err
+	})
+
+	return profile, session, err
+}
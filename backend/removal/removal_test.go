@@ -0,0 +1,155 @@
+package removal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/config"
+	"drafty3/migrations"
+)
+
+// newFixtureDB creates a db on disk, opened the way the server opens it and migrated with set
+func newFixtureDB(t *testing.T, name string, set migrations.Set) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(filepath.Join(t.TempDir(), name))), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db, set, 0); err != nil {
+		t.Fatalf("migrate %s: %v", name, err)
+	}
+	return db
+}
+
+// exec runs fixture statements and fails on the first error
+func exec(t *testing.T, db *gorm.DB, statements ...string) {
+	t.Helper()
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+}
+
+// count returns the result of a count query
+func count(t *testing.T, db *gorm.DB, query string, args ...interface{}) int64 {
+	t.Helper()
+
+	var n int64
+	if err := db.Raw(query, args...).Scan(&n).Error; err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+// TestRun removes profile 1, who asked for it, and leaves profile 2 alone
+func TestRun(t *testing.T) {
+	usersDB := newFixtureDB(t, "users.db", migrations.Users)
+	datasetDB := newFixtureDB(t, "dataset.db", migrations.Dataset)
+
+	exec(t, usersDB,
+		"INSERT INTO Profile (idProfile) VALUES (1), (2)",
+		"INSERT INTO Session (idSession, idProfile) VALUES (10, 1), (20, 2)",
+		"INSERT INTO sessions (idProfile, expires, data) VALUES (1, 0, ''), (2, 0, '')",
+	)
+	exec(t, datasetDB,
+		// profile 1 clicked, searched, edited, made a databait, answered a help us and commented
+		"INSERT INTO Interaction (idInteraction, idSession, idInteractionType) VALUES (100, 10, 1), (101, 10, 5), (102, 10, 2), (103, 10, 1), (104, 10, 1), (105, 10, 1)",
+		"INSERT INTO Click (idInteraction, idSuggestion) VALUES (100, 1)",
+		"INSERT INTO Search (idInteraction, idSuggestionType, value) VALUES (101, 1, 'x')",
+		"INSERT INTO Edit (IdInteraction, idEdit, idEntryType) VALUES (102, 1, 1)",
+		"INSERT INTO Databaits (idInteraction, idDatabaitTemplateType, idDatabaitCreateType, databait, notes) VALUES (103, 1, 1, 'd', '')",
+		"INSERT INTO HelpUs (idInteraction, idUniqueID, helpUsType, question) VALUES (104, 1, 't', 'q')",
+		"INSERT INTO Comments (idComment, idInteraction, idUniqueID, comment) VALUES (1, 105, 1, 'c')",
+		// profile 2 clicked
+		"INSERT INTO Interaction (idInteraction, idSession, idInteractionType) VALUES (200, 20, 1)",
+		"INSERT INTO Click (idInteraction, idSuggestion) VALUES (200, 1)",
+		// each wrote a suggestion
+		"INSERT INTO Suggestions (idSuggestion, idSuggestionType, idUniqueID, idProfile, suggestion) VALUES (1, 1, 1, 1, 'a'), (2, 2, 1, 2, 'b')",
+		"INSERT INTO RemoveUserData (id_profile, id_session) VALUES (1, 10)",
+	)
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	datasets := []Dataset{{Name: "test", DB: datasetDB}}
+	result, err := Run(usersDB, datasets, now)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Profiles) != 1 || result.Profiles[0] != 1 || result.Requests != 1 {
+		t.Fatalf("result = %+v, want profile 1 and 1 request", result)
+	}
+
+	// the tombstone and its session
+	var tombstoneID, tombstoneSessionID int64
+	if err := usersDB.Raw("SELECT idProfile FROM Profile WHERE username = ?", TombstoneUsername).Scan(&tombstoneID).Error; err != nil || tombstoneID == 0 {
+		t.Fatalf("tombstone profile not found: %v", err)
+	}
+	if err := usersDB.Raw("SELECT idSession FROM Session WHERE idProfile = ?", tombstoneID).Scan(&tombstoneSessionID).Error; err != nil || tombstoneSessionID == 0 {
+		t.Fatalf("tombstone session not found: %v", err)
+	}
+
+	checks := []struct {
+		name  string
+		db    *gorm.DB
+		query string
+		args  []interface{}
+		want  int64
+	}{
+		{"profile 1", usersDB, "SELECT COUNT(*) FROM Profile WHERE idProfile = 1", nil, 0},
+		{"sessions of profile 1", usersDB, "SELECT COUNT(*) FROM Session WHERE idProfile = 1", nil, 0},
+		{"cookie sessions of profile 1", usersDB, "SELECT COUNT(*) FROM sessions WHERE idProfile = 1", nil, 0},
+		{"profile 2", usersDB, "SELECT COUNT(*) FROM Profile WHERE idProfile = 2", nil, 1},
+		{"cookie sessions of profile 2", usersDB, "SELECT COUNT(*) FROM sessions WHERE idProfile = 2", nil, 1},
+		{"tracking rows of profile 1", datasetDB, "SELECT (SELECT COUNT(*) FROM Click WHERE idInteraction = 100) + (SELECT COUNT(*) FROM Search WHERE idInteraction = 101)", nil, 0},
+		{"comments of profile 1", datasetDB, "SELECT COUNT(*) FROM Comments", nil, 0},
+		{"tracking interactions of profile 1", datasetDB, "SELECT COUNT(*) FROM Interaction WHERE idInteraction IN (100, 101, 105)", nil, 0},
+		{"kept interactions moved to the tombstone", datasetDB, "SELECT COUNT(*) FROM Interaction WHERE idInteraction IN (102, 103, 104) AND idSession = ?", []interface{}{tombstoneSessionID}, 3},
+		{"interactions left with profile 1's session", datasetDB, "SELECT COUNT(*) FROM Interaction WHERE idSession = 10", nil, 0},
+		{"edit, databait and help us rows", datasetDB, "SELECT (SELECT COUNT(*) FROM Edit) + (SELECT COUNT(*) FROM Databaits) + (SELECT COUNT(*) FROM HelpUs)", nil, 3},
+		{"suggestion moved to the tombstone", datasetDB, "SELECT COUNT(*) FROM Suggestions WHERE idSuggestion = 1 AND idProfile = ?", []interface{}{tombstoneID}, 1},
+		{"suggestion of profile 2", datasetDB, "SELECT COUNT(*) FROM Suggestions WHERE idSuggestion = 2 AND idProfile = 2", nil, 1},
+		{"profile 2's click", datasetDB, "SELECT COUNT(*) FROM Interaction i JOIN Click c ON c.idInteraction = i.idInteraction WHERE i.idSession = 20", nil, 1},
+		{"completed requests", datasetDB, "SELECT COUNT(*) FROM RemoveUserData WHERE completed IS NOT NULL", nil, 1},
+	}
+	for _, check := range checks {
+		if got := count(t, check.db, check.query, check.args...); got != check.want {
+			t.Errorf("%s: got %d, want %d", check.name, got, check.want)
+		}
+	}
+
+	// a second run finds nothing to do and leaves everything as it was
+	profiles := count(t, usersDB, "SELECT COUNT(*) FROM Profile")
+	sessions := count(t, usersDB, "SELECT COUNT(*) FROM Session")
+	interactions := count(t, datasetDB, "SELECT COUNT(*) FROM Interaction")
+	result, err = Run(usersDB, datasets, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(result.Profiles) != 0 || result.Requests != 0 {
+		t.Fatalf("second run result = %+v, want nothing", result)
+	}
+	if got := count(t, usersDB, "SELECT COUNT(*) FROM Profile"); got != profiles {
+		t.Errorf("second run left %d profiles, want %d", got, profiles)
+	}
+	if got := count(t, usersDB, "SELECT COUNT(*) FROM Session"); got != sessions {
+		t.Errorf("second run left %d sessions, want %d", got, sessions)
+	}
+	if got := count(t, datasetDB, "SELECT COUNT(*) FROM Interaction"); got != interactions {
+		t.Errorf("second run left %d interactions, want %d", got, interactions)
+	}
+	if got := count(t, datasetDB, "SELECT COUNT(*) FROM RemoveUserData WHERE completed = ?", now); got != 1 {
+		t.Errorf("second run changed the completed time of the request")
+	}
+}
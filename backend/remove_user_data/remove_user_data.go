@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"drafty3/config"
+	"drafty3/removal"
+)
+
+// main function to read flags and error accordingly if issues and call run function for logic
+func main() {
+	// get flags and parse them
+	dbRoot := flag.String("db_root", "", "Directory of the dataset databases and datasets.yaml")
+	usersDBPath := flag.String("users_db", "", "Path to the users SQLite database file, defaults to the users db in --db_root")
+	flag.Parse()
+
+	// make sure required flags are provided
+	if *dbRoot == "" {
+		log.Fatal("missing required --db_root flag")
+	}
+	if *usersDBPath == "" {
+		*usersDBPath = filepath.Join(*dbRoot, config.UsersDBFileName)
+	}
+
+	// call the run function for the logic
+	if err := run(*dbRoot, *usersDBPath); err != nil {
+		log.Fatalf("remove_user_data failed: %v", err)
+	}
+}
+
+// run function to open the users db and every dataset db and process the pending requests
+func run(dbRoot, usersDBPath string) error {
+	// open the users db, making sure it exists so sqlite doesn't create an empty one
+	if _, err := os.Stat(usersDBPath); err != nil {
+		return fmt.Errorf("users database: %w", err)
+	}
+	usersDB, err := gorm.Open(sqlite.Open(config.SQLiteDSN(usersDBPath)), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("open users database: %w", err)
+	}
+
+	// open the same datasets the server mounts
+	registry, err := config.LoadDatasets(dbRoot)
+	if err != nil {
+		return fmt.Errorf("load datasets: %w", err)
+	}
+	datasets := make([]removal.Dataset, 0, len(registry))
+	for _, ds := range registry {
+		if _, err := os.Stat(ds.Path); err != nil {
+			return fmt.Errorf("%s database: %w", ds.Name, err)
+		}
+		db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(ds.Path)), &gorm.Config{})
+		if err != nil {
+			return fmt.Errorf("open %s database: %w", ds.Name, err)
+		}
+		datasets = append(datasets, removal.Dataset{Name: ds.Name, DB: db})
+	}
+
+	// remove the data and report what was done, including what finished before a failure
+	result, err := removal.Run(usersDB, datasets, time.Now())
+	log.Printf("removed %d profiles %v, completed %d requests", len(result.Profiles), result.Profiles, result.Requests)
+	return err
+}
@@ -0,0 +1,179 @@
+// Package sessionstore keeps session values in the users db sessions table so sessions can be listed and revoked
+// server side. the cookie only holds the signed id of the row.
+package sessionstore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"gorm.io/gorm"
+
+	"drafty3/go_migration/user_model"
+)
+
+// Store is a sessions.Store backed by the sessions table
+type Store struct {
+	DB      *gorm.DB
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+}
+
+// New returns a new Store for the users DB, signing the cookie with the key pairs the same way sessions.NewCookieStore does
+func New(db *gorm.DB, keyPairs ...[]byte) *Store {
+	s := &Store{
+		DB:     db,
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+	}
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// MaxAge sets how long new sessions last, for both the row and the cookie
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// Get returns the session cached for the request, loading it the first time
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session whose id is in the cookie, or returns a new one if the cookie is missing,
+// doesn't verify, or points to a row that expired or was revoked
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	// a cookie signed with a retired key or left from before this store starts over as a new session
+	var id string
+	if err := securecookie.DecodeMulti(name, c.Value, &id, s.Codecs...); err != nil {
+		return session, nil
+	}
+
+	var row user_model.Sessions
+	err = s.DB.First(&row, "session_id = ? AND expires > ?", id, time.Now().Unix()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+	if row.Data != nil {
+		raw, err := base64.StdEncoding.DecodeString(*row.Data)
+		if err != nil {
+			return session, err
+		}
+		if err := (securecookie.GobEncoder{}).Deserialize(raw, &session.Values); err != nil {
+			return session, err
+		}
+	}
+
+	session.ID = id
+	session.IsNew = false
+	return session, nil
+}
+
+// Save writes the session row and sets the cookie to its signed id. a session with a negative MaxAge
+// is deleted along with its cookie.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.DB.Delete(&user_model.Sessions{}, "session_id = ?", session.ID).Error; err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	// serialize the values and keep the profile in its own column so sessions can be listed per profile
+	raw, err := (securecookie.GobEncoder{}).Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(raw)
+	row := user_model.Sessions{
+		Expires: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second).Unix(),
+		Data:    &data,
+	}
+	if profileID, ok := session.Values["profile_id"].(int64); ok && profileID != 0 {
+		row.IDProfile = &profileID
+	}
+
+	// update the row of a loaded session or create one for a new session
+	if session.ID != "" {
+		row.SessionID, err = strconv.ParseInt(session.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		result := s.DB.Model(&user_model.Sessions{}).
+			Where("session_id = ?", row.SessionID).
+			Select("idProfile", "expires", "data").
+			Updates(&row)
+		if result.Error != nil {
+			return result.Error
+		}
+		// the session was revoked while the request ran so its cookie goes too
+		if result.RowsAffected == 0 {
+			opts := *session.Options
+			opts.MaxAge = -1
+			http.SetCookie(w, sessions.NewCookie(session.Name(), "", &opts))
+			return nil
+		}
+	} else {
+		if err := s.DB.Create(&row).Error; err != nil {
+			return err
+		}
+		session.ID = strconv.FormatInt(row.SessionID, 10)
+	}
+
+	// the cookie only carries the signed id
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Cleanup deletes expired sessions every interval until ctx is done
+func (s *Store) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result := s.DB.Delete(&user_model.Sessions{}, "expires <= ?", time.Now().Unix())
+			if result.Error != nil {
+				slog.Error("failed to delete expired sessions", "err", result.Error)
+			} else if result.RowsAffected > 0 {
+				slog.Info("deleted expired sessions", "count", result.RowsAffected)
+			}
+		}
+	}
+}
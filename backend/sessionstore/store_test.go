@@ -0,0 +1,282 @@
+package sessionstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"drafty3/config"
+	"drafty3/go_migration/user_model"
+	"drafty3/migrations"
+)
+
+const cookieName = "session"
+
+// newTestStore creates a store on a migrated users db on disk, signing with the key pairs
+func newTestStore(t *testing.T, keys ...[]byte) *Store {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(filepath.Join(t.TempDir(), "users.db"))), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db, migrations.Users, 0); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	s := New(db, keys...)
+	s.MaxAge(1200)
+	return s
+}
+
+// newRequest returns a request carrying cookie, or none if it's nil
+func newRequest(cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+// responseCookie returns the session cookie a response set
+func responseCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == cookieName {
+			return c
+		}
+	}
+	t.Fatal("response set no session cookie")
+	return nil
+}
+
+// saveNewSession saves a new session of profile with s and returns its cookie
+func saveNewSession(t *testing.T, s *Store, profileID int64) *http.Cookie {
+	t.Helper()
+
+	r := newRequest(nil)
+	session, err := s.New(r, cookieName)
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	session.Values["profile_id"] = profileID
+	w := httptest.NewRecorder()
+	if err := s.Save(r, w, session); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	return responseCookie(t, w)
+}
+
+// countRows returns how many session rows s has
+func countRows(t *testing.T, s *Store) int64 {
+	t.Helper()
+
+	var n int64
+	if err := s.DB.Model(&user_model.Sessions{}).Count(&n).Error; err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+	return n
+}
+
+func TestNewAndSave(t *testing.T) {
+	key := securecookie.GenerateRandomKey(32)
+	oldKey := securecookie.GenerateRandomKey(32)
+	retiredKey := securecookie.GenerateRandomKey(32)
+
+	tests := []struct {
+		name string
+		// cookie makes the cookie the request carries, or none if it's nil
+		cookie func(t *testing.T, s *Store) *http.Cookie
+		// beforeSave changes the session or its row while the request runs
+		beforeSave func(t *testing.T, s *Store, session *sessions.Session)
+		// wantNew is whether New starts a new session rather than loading one
+		wantNew bool
+		// wantRows is how many session rows there are after Save
+		wantRows int64
+		// wantDeleted is whether Save deletes the cookie
+		wantDeleted bool
+	}{
+		{
+			name:     "new session",
+			wantNew:  true,
+			wantRows: 1,
+		},
+		{
+			name: "loaded session",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				return saveNewSession(t, s, 7)
+			},
+			wantRows: 1,
+		},
+		{
+			name: "loaded session signed with a rotated key",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				old := *s
+				old.Codecs = securecookie.CodecsFromPairs(oldKey)
+				return saveNewSession(t, &old, 7)
+			},
+			wantRows: 1,
+		},
+		{
+			name: "revoked session",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				return saveNewSession(t, s, 7)
+			},
+			beforeSave: func(t *testing.T, s *Store, session *sessions.Session) {
+				if err := s.DB.Delete(&user_model.Sessions{}, "session_id = ?", session.ID).Error; err != nil {
+					t.Fatalf("revoke session: %v", err)
+				}
+			},
+			wantRows:    0,
+			wantDeleted: true,
+		},
+		{
+			name: "session with negative MaxAge",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				return saveNewSession(t, s, 7)
+			},
+			beforeSave: func(t *testing.T, s *Store, session *sessions.Session) {
+				session.Options.MaxAge = -1
+			},
+			wantRows:    0,
+			wantDeleted: true,
+		},
+		{
+			name: "cookie signed with a retired key",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				retired := *s
+				retired.Codecs = securecookie.CodecsFromPairs(retiredKey)
+				return saveNewSession(t, &retired, 7)
+			},
+			wantNew: true,
+			// the row of the retired cookie is left for cleanup
+			wantRows: 2,
+		},
+		{
+			name: "expired row",
+			cookie: func(t *testing.T, s *Store) *http.Cookie {
+				c := saveNewSession(t, s, 7)
+				if err := s.DB.Model(&user_model.Sessions{}).Where("1 = 1").
+					Update("expires", time.Now().Add(-time.Minute).Unix()).Error; err != nil {
+					t.Fatalf("expire session: %v", err)
+				}
+				return c
+			},
+			wantNew:  true,
+			wantRows: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, key, nil, oldKey, nil)
+
+			var cookie *http.Cookie
+			if tt.cookie != nil {
+				cookie = tt.cookie(t, s)
+			}
+			r := newRequest(cookie)
+
+			session, err := s.New(r, cookieName)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			if session.IsNew != tt.wantNew {
+				t.Fatalf("IsNew = %v, want %v", session.IsNew, tt.wantNew)
+			}
+			if !tt.wantNew && session.Values["profile_id"] != int64(7) {
+				t.Fatalf("loaded values = %v, want profile_id 7", session.Values)
+			}
+			loadedID := session.ID
+
+			session.Values["profile_id"] = int64(8)
+			if tt.beforeSave != nil {
+				tt.beforeSave(t, s, session)
+			}
+			w := httptest.NewRecorder()
+			if err := s.Save(r, w, session); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			if got := countRows(t, s); got != tt.wantRows {
+				t.Errorf("rows = %d, want %d", got, tt.wantRows)
+			}
+			saved := responseCookie(t, w)
+			if deleted := saved.MaxAge < 0; deleted != tt.wantDeleted {
+				t.Fatalf("cookie deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if tt.wantDeleted {
+				return
+			}
+
+			// a loaded session keeps its row, and the saved cookie loads what was saved
+			if !tt.wantNew && session.ID != loadedID {
+				t.Errorf("saved session id = %s, want %s", session.ID, loadedID)
+			}
+			reloaded, err := s.New(newRequest(saved), cookieName)
+			if err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if reloaded.IsNew || reloaded.ID != session.ID || reloaded.Values["profile_id"] != int64(8) {
+				t.Fatalf("reloaded session %s new=%v values=%v, want %s with profile_id 8",
+					reloaded.ID, reloaded.IsNew, reloaded.Values, session.ID)
+			}
+			var row user_model.Sessions
+			if err := s.DB.First(&row, "session_id = ?", session.ID).Error; err != nil {
+				t.Fatalf("find row: %v", err)
+			}
+			if row.IDProfile == nil || *row.IDProfile != 8 {
+				t.Errorf("row profile = %v, want 8", row.IDProfile)
+			}
+		})
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	s := newTestStore(t, securecookie.GenerateRandomKey(32))
+	now := time.Now()
+	if err := s.DB.Create(&[]user_model.Sessions{
+		{Expires: now.Add(-time.Minute).Unix()},
+		{Expires: now.Add(time.Hour).Unix()},
+	}).Error; err != nil {
+		t.Fatalf("seed sessions: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Cleanup(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	// wait for a tick to delete the expired row
+	deadline := time.Now().Add(2 * time.Second)
+	for countRows(t, s) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	var rows []user_model.Sessions
+	if err := s.DB.Find(&rows).Error; err != nil {
+		t.Fatalf("find sessions: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Expires <= now.Unix() {
+		t.Fatalf("sessions after cleanup = %+v, want only the live one", rows)
+	}
+}
@@ -3,7 +3,7 @@ import "@glideapps/glide-data-grid/dist/index.css";
 import React, { useState, useEffect } from 'react';
 import { Snackbar } from '@mui/material';
 import { CompactSelection, type BubbleCell, type EditableGridCell, type GridSelection, type Item, type GridColumn } from "@glideapps/glide-data-grid";
-import { fetchCsvData } from '../utils/csvParser';
+import { fetchCsvData, columnSchemaFromBackend } from '../utils/csvParser';
 import type { ColumnData } from '../interfaces/ColumnData';
 import useWindowWidth from '../hooks/useWindow';
 import Alert from './Alerts';
@@ -19,7 +19,7 @@ import DeleteRowFooter from './DeleteRowFooter';
 import { ensureSession, type BackendSession } from "../lib/sessions";
 import { recordCellClick, recordCellEdit, recordColumnSearch, recordRowAdd, recordRowDelete } from "../lib/interactions"
 import type { ColumnConfig } from '../interfaces/ColumnData';
-import { getColumnId, getSuggestionTypeValues } from "../lib/edits";
+import { getColumnId, getColumns, getSuggestionTypeValues, type ColumnDefinition } from "../lib/edits";
 import { checkBackendHealth } from '../lib/health';
 import FreeTextModal from './FreeTextModal';
 import { datasetFiles, datasetLabels } from "../config/AppConfig";
@@ -123,11 +123,22 @@ export default function App() {
         setBackendAvailable(isHealthy);
         console.log("Backend health:", isHealthy);
 
+        // the backend's columns drive the grid when they all have hints, otherwise the yaml does
+        const backendColumns = isHealthy
+          ? await new Promise<ColumnDefinition[]>((resolve) => {
+              getColumns(
+                (cols) => resolve(cols ?? []),
+                () => resolve([])
+              );
+            })
+          : [];
+
         const { gridColumns, parsedData, optionsLists, columnSchema } =
           await fetchCsvData(
             gridWidth,
             `${base}${datasetFiles[dataset].csv}`,
-            `${base}${datasetFiles[dataset].yaml}`
+            `${base}${datasetFiles[dataset].yaml}`,
+            columnSchemaFromBackend(backendColumns)
           );
   
         console.log("Grid Columns:", gridColumns);
@@ -143,7 +154,16 @@ export default function App() {
 
         const schemaColumnNames = Object.keys(columnSchema);
 
-        if (isHealthy) {
+        if (backendColumns.length > 0) {
+          const idMap: Record<string, number> = {};
+
+          for (const col of backendColumns) {
+            if (col.name) idMap[col.name] = col.idSuggestionType;
+          }
+
+          setSuggestionTypeIds(idMap);
+        }
+        else if (isHealthy) {
           const idPairs = await Promise.all(
             schemaColumnNames.map(
               (name) =>
@@ -1,4 +1,5 @@
 import { getAPI } from "./api";
+import type { ColumnType, EditType } from "../interfaces/ColumnData";
 
 // base API call for edit-related GET requests.
 export function getEditInformation<T>(
@@ -71,4 +72,37 @@ export function getSuggestionTypeValues(
     onSuccess,
     onError
   );
-}
\ No newline at end of file
+}
+// type for a column from getColumns, its full SuggestionType with the hints the grid shows it with
+export type ColumnDefinition = {
+  idSuggestionType: number;
+  name: string | null;
+  idDataType: number;
+  dataType: string | null;
+  regex: string;
+  makesRowUnique: number | null;
+  canBeBlank: number;
+  isFreeEdit: number;
+  isDate: number;
+  isLink: number;
+  isCurrency: number;
+  isEditable: number;
+  columnOrder: number | null;
+  hints: {
+    type?: ColumnType;
+    width?: string;
+    edit?: EditType;
+  };
+};
+
+// get every active column of the dataset in display order
+export function getColumns(
+  onSuccess?: (data: ColumnDefinition[], res: Response) => void,
+  onError?: (err: Response | Error) => void
+) {
+  getEditInformation<ColumnDefinition[]>(
+    `${getAPI()}/columns`,
+    onSuccess,
+    onError
+  );
+}
@@ -1,17 +1,49 @@
 import { getAPI } from "./api";
 
-// check health of backend server
-export async function checkBackendHealth(): Promise<boolean> {
+// "down" means the backend didn't answer, "db_broken" means it answered but a database failed a check
+export type BackendHealth = "healthy" | "db_broken" | "down";
+
+// a database in the health response and whether it passed every check
+type DatabaseHealth = {
+  name: string;
+  ok: boolean;
+  checks: Record<string, { ok: boolean; value?: unknown; error?: string }>;
+};
+
+// get health of backend server and its databases
+export async function getBackendHealth(): Promise<BackendHealth> {
+  let res: Response;
   try {
-    const res = await fetch(`${getAPI()}/health`, {
+    res = await fetch(`${getAPI()}/health`, {
       method: "GET",
       credentials: "include",
     });
-
-    return res.ok;
-  } 
+  }
   catch (err) {
     console.error("Network error checking health:", err);
-    return false;
+    return "down";
   }
-}
\ No newline at end of file
+
+  if (res.ok) return "healthy";
+
+  // a 503 with a body is the backend reporting a broken database
+  if (res.status === 503) {
+    try {
+      const body: { databases?: DatabaseHealth[] } = await res.json();
+      const failing = (body.databases ?? []).filter((db) => !db.ok);
+      console.error("Backend database checks failed:", failing);
+      return "db_broken";
+    }
+    catch {
+      // not the health response, so something in front of the backend answered
+    }
+  }
+
+  console.error("Backend health check failed with status", res.status);
+  return "down";
+}
+
+// check health of backend server
+export async function checkBackendHealth(): Promise<boolean> {
+  return (await getBackendHealth()) === "healthy";
+}
@@ -1,5 +1,6 @@
 import type { ColumnData } from "../interfaces/ColumnData";
 import { getAPI } from "./api";
+import { lookupID } from "./meta";
 
 // base API call for interactions
 export function recordInteraction(
@@ -31,7 +32,7 @@ export function recordInteraction(
 }
 
 // cell click
-export function recordCellClick(
+export async function recordCellClick(
   idSuggestionType: number,
   idUniqueID: number,
   rowValues: ColumnData
@@ -41,7 +42,7 @@ export function recordCellClick(
   recordInteraction(
     `${getAPI()}/clicks`,
     {
-      IDInteractionType: 1, // placeholder
+      IDInteractionType: await lookupID("interactiontypes", "click", 1),
       IDSuggestionType: idSuggestionType,
       IDUniqueID:       idUniqueID,
       RowValues:        rowValuesString,
@@ -50,7 +51,7 @@ export function recordCellClick(
 }
 
 // cell edit
-export function recordCellEdit(
+export async function recordCellEdit(
   idSuggestionType: number,
   idUniqueID: number,
   suggestion: string,
@@ -58,8 +59,8 @@ export function recordCellEdit(
   recordInteraction(
     `${getAPI()}/edits`,
     {
-      IDInteractionType: 2, // placeholder
-      IDEntryType: 1, // placeholder
+      IDInteractionType: await lookupID("interactiontypes", "edit", 2),
+      IDEntryType: await lookupID("entrytypes", "edit", 1),
       Mode: "normal", // default
       IsCorrect: 2, // default
       IDSuggestionType: idSuggestionType,
@@ -71,7 +72,7 @@ export function recordCellEdit(
 }
 
 // column search
-export function recordColumnSearch(
+export async function recordColumnSearch(
   value: string,
   matchedValues: any,
   isPartial: boolean,
@@ -81,9 +82,9 @@ export function recordColumnSearch(
   recordInteraction(
     `${getAPI()}/searches`,
     {
-      IDInteractionType: 2, // placeholder
+      IDInteractionType: await lookupID("interactiontypes", "search", 5),
       IDSuggestionType: 1, // placeholder
-      IDSearchType: 1, // placeholder
+      IDSearchType: await lookupID("searchtypes", "column", 1),
 
       IsPartial:  isPartial ? 1 : 0,
       IsMulti:    isMulti ? 1 : 0,
@@ -96,7 +97,7 @@ export function recordColumnSearch(
 }
 
 // row add
-export function recordRowAdd(
+export async function recordRowAdd(
   cells: {
     IDSuggestionType: number;
     Suggestion: string;
@@ -107,8 +108,8 @@ export function recordRowAdd(
   onError?: (res: Response | Error) => void
 ) {
   recordInteraction(`${getAPI()}/editnewrows`, {
-    IDInteractionType: 3, // placeholder
-    IDEntryType: 2, // placeholder
+    IDInteractionType: await lookupID("interactiontypes", "new row", 3),
+    IDEntryType: await lookupID("entrytypes", "new row", 2),
     Mode: "normal", // default
     IsCorrect: 2, // default
     Cells: cells,
@@ -116,15 +117,15 @@ export function recordRowAdd(
 }
 
 // row delete
-export function recordRowDelete(
+export async function recordRowDelete(
   idUniqueID: number,
   comment: string
 ) {
   recordInteraction(
     `${getAPI()}/editdelrows`,
     {
-      IDInteractionType: 4, // placeholder
-      IDEntryType: 3, // placeholder
+      IDInteractionType: await lookupID("interactiontypes", "delete row", 4),
+      IDEntryType: await lookupID("entrytypes", "delete row", 3),
       IDUniqueID: idUniqueID,
       Comment: comment,
       Mode: "normal", // default
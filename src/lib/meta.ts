@@ -0,0 +1,58 @@
+import { getAPI } from "./api";
+import type { ColumnDefinition } from "./edits";
+
+// a row of a lookup table, whatever its columns are called in the db
+export type LookupRow = {
+  id: number;
+  name: string | null;
+};
+
+// lookup tables served by GET /meta, keyed like their routes
+export type LookupTable =
+  | "datatypes"
+  | "databaitcreatetypes"
+  | "databaitnextactions"
+  | "databaittemplatetypes"
+  | "entrytypes"
+  | "interactiontypes"
+  | "roles"
+  | "searchtypes";
+
+// the columns are the same as getColumns returns
+export type Meta = Record<LookupTable, LookupRow[]> & {
+  suggestiontypes: ColumnDefinition[];
+};
+
+// the meta of the dataset, fetched once per page load
+let metaRequest: Promise<Meta | null> | null = null;
+
+// get every lookup table and column of the dataset, or null if the backend didn't answer
+export function getMeta(): Promise<Meta | null> {
+  if (!metaRequest) {
+    metaRequest = fetch(`${getAPI()}/meta`, {
+      method: "GET",
+      credentials: "include",
+    })
+      .then(res => {
+        if (!res.ok) throw new Error(`status ${res.status}`);
+        return res.json() as Promise<Meta>;
+      })
+      .catch(err => {
+        console.error("Error fetching meta:", err);
+        // try again on the next lookup
+        metaRequest = null;
+        return null;
+      });
+  }
+  return metaRequest;
+}
+
+// get the id of the row of a lookup table with the given name, or fallback if there's no such row
+export async function lookupID(table: LookupTable, name: string, fallback: number): Promise<number> {
+  const meta = await getMeta();
+  const row = meta?.[table].find(r => r.name?.toLowerCase() === name.toLowerCase());
+  if (row) return row.id;
+
+  console.warn(`No ${table} row named "${name}", using id ${fallback}`);
+  return fallback;
+}
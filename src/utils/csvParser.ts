@@ -3,9 +3,10 @@ import type { GridColumn } from "@glideapps/glide-data-grid";
 import type { ColumnData } from '../interfaces/ColumnData';
 import { generateOptionsLists, generateColumnWidths } from './constants';
 import yaml from 'js-yaml'; 
-import type { EditType, ColumnConfig } from '../interfaces/ColumnData';
+import type { EditType, ColumnConfig, ColumnType } from '../interfaces/ColumnData';
+import type { ColumnDefinition } from '../lib/edits';
 
-type YamlSchema = Record<string, ColumnConfig>;
+export type YamlSchema = Record<string, ColumnConfig>;
 
 /*
   Attempt to fetch and parse a YAML schema file that defines the column types, edit types, and widths.
@@ -61,6 +62,29 @@ const fetchYamlSchema = async (yamlFilePath?: string): Promise<YamlSchema> => {
   }
 };
 
+/*
+  Build a column schema from the columns the backend serves, keyed by column name.
+  If there are no columns or any is missing its type, return undefined so the YAML file is used instead.
+*/
+
+export const columnSchemaFromBackend = (columns: ColumnDefinition[]): YamlSchema | undefined => {
+  if (columns.length === 0 || columns.some((col) => !col.name || !col.hints.type)) {
+    return undefined;
+  }
+
+  const columnSchema: YamlSchema = {};
+
+  for (const col of columns) {
+    columnSchema[col.name as string] = {
+      type: col.hints.type as ColumnType,
+      edit: col.hints.edit,
+      width: col.hints.width,
+    };
+  }
+
+  return columnSchema;
+};
+
 /*
   Fetch and parse CSV data from the specified file, optionally using a YAML schema to determine column types.
 
@@ -68,6 +92,7 @@ const fetchYamlSchema = async (yamlFilePath?: string): Promise<YamlSchema> => {
   csvFilePath - The path to the CSV file.
   customWidths - Optional custom percentages for specific columns.
   yamlSchemaFilePath - Optional path to a YAML file that defines column types.
+  backendSchema - Optional column types from the backend, used instead of the YAML file when given.
   
   returns An object containing dynamically generated grid columns, parsed data rows, and options lists.
 */
@@ -75,7 +100,8 @@ const fetchYamlSchema = async (yamlFilePath?: string): Promise<YamlSchema> => {
 export const fetchCsvData = async (
   gridWidth: number,
   csvFilePath: string, 
-  yamlSchemaFilePath?: string
+  yamlSchemaFilePath?: string,
+  backendSchema?: YamlSchema
 ): Promise<{ 
   gridColumns: GridColumn[]; 
   parsedData: ColumnData[]; 
@@ -83,7 +109,7 @@ export const fetchCsvData = async (
   columnSchema: YamlSchema; 
 }> => {
   try {
-    const columnSchema = await fetchYamlSchema(yamlSchemaFilePath);
+    const columnSchema = backendSchema ?? await fetchYamlSchema(yamlSchemaFilePath);
     const response = await fetch(csvFilePath);
     const csvData = await response.text();
 